@@ -0,0 +1,89 @@
+package sleepme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AboutComplete reports whether the device reported all of its About fields. Devices on a LAN-only
+// setup, for example, don't report an IP address
+func (d *DeviceDetails) AboutComplete() bool {
+	a := d.About
+	return a.FirmwareVersion != "" && a.IpAddress != "" && a.LanAddress != "" && a.MacAddress != "" &&
+		a.Model != "" && a.SerialNumber != ""
+}
+
+// IPAddress parses About.IpAddress. It returns nil without an error if the device doesn't report one
+func (d *DeviceDetails) IPAddress() (net.IP, error) {
+	return parseIP("ip_address", d.About.IpAddress)
+}
+
+// LANAddress parses About.LanAddress. It returns nil without an error if the device doesn't report one
+func (d *DeviceDetails) LANAddress() (net.IP, error) {
+	return parseIP("lan_address", d.About.LanAddress)
+}
+
+// PublicIP returns the address the device reaches the cloud from, as reported in About.IpAddress.
+// For devices behind a router, this is the public address of the router. It returns nil for missing
+// or invalid addresses; use IPAddress to tell them apart
+func (d *DeviceDetails) PublicIP() net.IP {
+	ip, _ := d.IPAddress()
+	return ip
+}
+
+// LocalIP returns the address of the device in its local network, as reported in About.LanAddress.
+// It returns nil for missing or invalid addresses; use LANAddress to tell them apart
+func (d *DeviceDetails) LocalIP() net.IP {
+	ip, _ := d.LANAddress()
+	return ip
+}
+
+// IsOnLocalNetwork reports whether the LocalIP of the device is within localSubnet, e.g. the subnet of
+// the machine running the client
+func (d *DeviceDetails) IsOnLocalNetwork(localSubnet *net.IPNet) bool {
+	ip := d.LocalIP()
+	return ip != nil && localSubnet != nil && localSubnet.Contains(ip)
+}
+
+// HardwareAddress parses About.MacAddress. It returns nil without an error if the device doesn't report one
+func (d *DeviceDetails) HardwareAddress() (net.HardwareAddr, error) {
+	if d.About.MacAddress == "" {
+		return nil, nil
+	}
+	mac, err := net.ParseMAC(d.About.MacAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac_address %q", d.About.MacAddress)
+	}
+	return mac, nil
+}
+
+// Fingerprint returns a stable identifier of the hardware, e.g. for keying storage of devices across renames.
+// It is a hash of the serial number and MAC address only, so it survives changes of the name, IP addresses
+// and firmware. Both are normalized first: case and the notation of the MAC address don't matter.
+// It returns an empty string for devices reporting neither
+func (d *DeviceDetails) Fingerprint() string {
+	serial := strings.ToUpper(strings.TrimSpace(d.About.SerialNumber))
+	mac := strings.ToLower(strings.TrimSpace(d.About.MacAddress))
+	if parsed, err := net.ParseMAC(mac); err == nil {
+		mac = parsed.String()
+	}
+	if serial == "" && mac == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(serial + "\x00" + mac))
+	return hex.EncodeToString(sum[:16])
+}
+
+func parseIP(field, s string) (net.IP, error) {
+	if s == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid %s %q", field, s)
+	}
+	return ip, nil
+}
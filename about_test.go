@@ -0,0 +1,131 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestAboutAddresses(t *testing.T) {
+	var details DeviceDetails
+	assert.NilError(t, json.Unmarshal([]byte(`{"about":{
+		"firmware_version":"5.39.2","ip_address":"203.0.113.7","lan_address":"192.168.1.20",
+		"mac_address":"a4:cf:12:34:56:78","model":"DP999NA","serial_number":"123"}}`), &details))
+	assert.Assert(t, details.AboutComplete())
+
+	ip, err := details.IPAddress()
+	assert.NilError(t, err)
+	assert.Assert(t, ip.Equal(net.ParseIP("203.0.113.7")))
+	lan, err := details.LANAddress()
+	assert.NilError(t, err)
+	assert.Assert(t, lan.Equal(net.ParseIP("192.168.1.20")))
+	mac, err := details.HardwareAddress()
+	assert.NilError(t, err)
+	assert.Equal(t, mac.String(), "a4:cf:12:34:56:78")
+}
+
+func TestAboutAddressesMissing(t *testing.T) {
+	var details DeviceDetails
+	assert.Assert(t, !details.AboutComplete())
+
+	ip, err := details.IPAddress()
+	assert.NilError(t, err)
+	assert.Assert(t, ip == nil)
+	lan, err := details.LANAddress()
+	assert.NilError(t, err)
+	assert.Assert(t, lan == nil)
+	mac, err := details.HardwareAddress()
+	assert.NilError(t, err)
+	assert.Assert(t, mac == nil)
+}
+
+func TestAboutAddressesMalformed(t *testing.T) {
+	var details DeviceDetails
+	assert.NilError(t, json.Unmarshal([]byte(`{"about":{"ip_address":"300.1.1.1","lan_address":"lan","mac_address":"zz:zz"}}`), &details))
+
+	_, err := details.IPAddress()
+	assert.Error(t, err, `invalid ip_address "300.1.1.1"`)
+	_, err = details.LANAddress()
+	assert.Error(t, err, `invalid lan_address "lan"`)
+	_, err = details.HardwareAddress()
+	assert.Error(t, err, `invalid mac_address "zz:zz"`)
+}
+
+func TestWithLocalAccessUsesAPIEndpoint(t *testing.T) {
+	var gets int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		var details DeviceDetails
+		details.About.LanAddress = "192.0.2.1"
+		json.NewEncoder(w).Encode(details)
+	}), WithLocalAccess())
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Get(context.Background(), "device")
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, gets, 2)
+}
+
+func TestFingerprint(t *testing.T) {
+	details := func(name, ip, serial, mac string) *DeviceDetails {
+		var d DeviceDetails
+		d.About.Model = name
+		d.About.IpAddress = ip
+		d.About.SerialNumber = serial
+		d.About.MacAddress = mac
+		return &d
+	}
+
+	fingerprint := details("left", "203.0.113.7", "SN123", "aa:bb:cc:dd:ee:ff").Fingerprint()
+	assert.Equal(t, len(fingerprint), 32)
+	for _, d := range []*DeviceDetails{
+		details("right", "203.0.113.8", "SN123", "aa:bb:cc:dd:ee:ff"),
+		details("left", "", "sn123 ", "AA-BB-CC-DD-EE-FF"),
+	} {
+		assert.Equal(t, d.Fingerprint(), fingerprint)
+	}
+	for _, d := range []*DeviceDetails{
+		details("left", "203.0.113.7", "SN124", "aa:bb:cc:dd:ee:ff"),
+		details("left", "203.0.113.7", "SN123", "aa:bb:cc:dd:ee:00"),
+		details("left", "203.0.113.7", "SN123aa:bb:cc:dd:ee:ff", ""),
+	} {
+		assert.Assert(t, d.Fingerprint() != fingerprint, "serial %q, mac %q", d.About.SerialNumber, d.About.MacAddress)
+	}
+	assert.Equal(t, details("left", "203.0.113.7", "", "").Fingerprint(), "")
+}
+
+func TestPublicAndLocalIP(t *testing.T) {
+	var details DeviceDetails
+	details.About.IpAddress = "203.0.113.7"
+	details.About.LanAddress = "192.168.1.23"
+	assert.Assert(t, details.PublicIP().Equal(net.ParseIP("203.0.113.7")))
+	assert.Assert(t, details.LocalIP().Equal(net.ParseIP("192.168.1.23")))
+
+	for _, tc := range []struct {
+		subnet string
+		want   bool
+	}{
+		{subnet: "192.168.1.0/24", want: true},
+		{subnet: "192.168.0.0/16", want: true},
+		{subnet: "192.168.2.0/24"},
+		{subnet: "10.0.0.0/8"},
+		{subnet: "203.0.113.0/24"},
+		{subnet: "fd00::/8"},
+	} {
+		_, subnet, err := net.ParseCIDR(tc.subnet)
+		assert.NilError(t, err)
+		assert.Equal(t, details.IsOnLocalNetwork(subnet), tc.want, "subnet %s", tc.subnet)
+	}
+	assert.Assert(t, !details.IsOnLocalNetwork(nil))
+
+	details.About.IpAddress = ""
+	details.About.LanAddress = "not an address"
+	assert.Assert(t, details.PublicIP() == nil)
+	assert.Assert(t, details.LocalIP() == nil)
+	_, subnet, _ := net.ParseCIDR("0.0.0.0/0")
+	assert.Assert(t, !details.IsOnLocalNetwork(subnet))
+}
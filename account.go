@@ -0,0 +1,48 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Account describes the authenticated sleep.me user
+type Account struct {
+	Name            string                 `json:"name"`
+	Email           string                 `json:"email"`
+	TemperatureUnit DisplayTemperatureUnit `json:"temperature_unit"`
+}
+
+// Me returns the account the token belongs to. The API has no account endpoint, so it returns ErrNotSupported
+func (c *Client) Me(ctx context.Context) (*Account, error) {
+	return nil, ErrNotSupported
+}
+
+// WithPreferredUnitFromAccount makes unit-agnostic setters like SetTemperature use the temperature unit
+// preferred by the account, as reported by Me. Fahrenheit is used if the account doesn't report one,
+// including while the API doesn't expose accounts
+func WithPreferredUnitFromAccount(ctx context.Context) func(*Client) error {
+	return func(c *Client) error {
+		account, err := c.Me(ctx)
+		unit, err := preferredUnit(account, err)
+		if err != nil {
+			return err
+		}
+		c.preferredUnit = unit
+		return nil
+	}
+}
+
+// preferredUnit returns the unit preferred by account, falling back to Fahrenheit if the account is unavailable
+func preferredUnit(account *Account, err error) (DisplayTemperatureUnit, error) {
+	if errors.Is(err, ErrNotSupported) {
+		return DisplayTemperatureUnitF, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the preferred temperature unit: %w", err)
+	}
+	if account.TemperatureUnit.valid() {
+		return account.TemperatureUnit, nil
+	}
+	return DisplayTemperatureUnitF, nil
+}
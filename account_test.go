@@ -0,0 +1,60 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func TestMeIsNotSupported(t *testing.T) {
+	var calls int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	account, err := c.Me(context.Background())
+	assert.Assert(t, errors.Is(err, ErrNotSupported), "expected not supported, got %v", err)
+	assert.Assert(t, account == nil)
+	assert.Equal(t, calls, 0)
+}
+
+func TestPreferredUnitFromAccount(t *testing.T) {
+	for _, tc := range []struct {
+		account *Account
+		err     error
+		want    DisplayTemperatureUnit
+	}{
+		{err: ErrNotSupported, want: DisplayTemperatureUnitF},
+		{account: &Account{TemperatureUnit: DisplayTemperatureUnitC}, want: DisplayTemperatureUnitC},
+		{account: &Account{TemperatureUnit: DisplayTemperatureUnitF}, want: DisplayTemperatureUnitF},
+		{account: &Account{}, want: DisplayTemperatureUnitF},
+	} {
+		unit, err := preferredUnit(tc.account, tc.err)
+		assert.NilError(t, err)
+		assert.Equal(t, unit, tc.want)
+	}
+
+	_, err := preferredUnit(nil, &StatusError{StatusCode: http.StatusUnauthorized})
+	assert.ErrorContains(t, err, "expected 200, got 401")
+}
+
+func TestSetTemperatureUsesPreferredUnit(t *testing.T) {
+	var bodies []map[string]interface{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+	})
+
+	c := newTestClient(t, handler, WithPreferredUnitFromAccount(context.Background()))
+	assert.NilError(t, c.SetTemperature(context.Background(), "device", 70))
+	c.preferredUnit = DisplayTemperatureUnitC
+	assert.NilError(t, c.SetTemperature(context.Background(), "device", 21))
+	assert.DeepEqual(t, bodies, []map[string]interface{}{
+		{"set_temperature_f": float64(70)},
+		{"set_temperature_c": float64(21)},
+	})
+}
@@ -0,0 +1,25 @@
+package sleepme
+
+import (
+	"context"
+	"time"
+)
+
+// Alarm is a wake alarm of a Dock Pro
+type Alarm struct {
+	ID string `json:"id"`
+	// Time is the next time the alarm goes off
+	Time time.Time `json:"time"`
+	// Days lists the weekdays the alarm repeats on, empty for a one-off alarm
+	Days    []time.Weekday `json:"days"`
+	Enabled bool           `json:"enabled"`
+	// SetTemperatureF is the temperature the Dock Pro changes to when the alarm goes off, if any
+	SetTemperatureF *float64 `json:"set_temperature_f,omitempty"`
+	// ThermalControlStatus is the status the Dock Pro changes to when the alarm goes off, if any
+	ThermalControlStatus *ThermalControlStatus `json:"thermal_control_status,omitempty"`
+}
+
+// NextAlarm returns the next enabled wake alarm of a Dock Pro. Alarms aren't part of the API; NextAlarm returns ErrNotSupported
+func (c *Client) NextAlarm(ctx context.Context, deviceID string) (*Alarm, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,21 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func TestNextAlarmIsNotSupported(t *testing.T) {
+	var calls int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	alarm, err := c.NextAlarm(context.Background(), "device")
+	assert.Assert(t, errors.Is(err, ErrNotSupported), "expected not supported, got %v", err)
+	assert.Assert(t, alarm == nil)
+	assert.Equal(t, calls, 0)
+}
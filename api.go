@@ -0,0 +1,19 @@
+package sleepme
+
+import "context"
+
+// DeviceAPI contains the device operations of the sleep.me API. *Client implements it;
+// code depending on DeviceAPI instead of *Client can substitute a fake in tests
+type DeviceAPI interface {
+	ListDevices(ctx context.Context) ([]Device, error)
+	Get(ctx context.Context, deviceID string) (*DeviceDetails, error)
+	Update(ctx context.Context, deviceID string, r UpdateRequest) error
+	UpdateWithResult(ctx context.Context, deviceID string, r UpdateRequest) (*DeviceDetails, error)
+	DeviceExists(ctx context.Context, deviceID string) (bool, error)
+	DeviceByName(ctx context.Context, name string) (*Device, error)
+	SetTemperatureF(ctx context.Context, deviceID string, f float64) error
+	SetTemperatureC(ctx context.Context, deviceID string, celsius float64) error
+	SetClimate(ctx context.Context, deviceID string, status ThermalControlStatus, temp float64, unit DisplayTemperatureUnit) error
+}
+
+var _ DeviceAPI = (*Client)(nil)
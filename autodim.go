@@ -0,0 +1,22 @@
+package sleepme
+
+import "context"
+
+// AutoDim describes when a Dock Pro dims its display
+type AutoDim struct {
+	Enabled bool `json:"enabled"`
+	// Start and End are wall times like 22:30 in the time zone of the device
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// GetAutoDim returns the display dimming schedule of a Dock Pro, or ErrNotSupported while the API doesn't offer it
+func (c *Client) GetAutoDim(ctx context.Context, deviceID string) (*AutoDim, error) {
+	return nil, ErrNotSupported
+}
+
+// SetAutoDim turns the display dimming schedule of a Dock Pro on or off. It returns ErrNotSupported;
+// use UpdateRequest.BrightnessLevel to change the brightness instead
+func (c *Client) SetAutoDim(ctx context.Context, deviceID string, enabled bool) error {
+	return ErrNotSupported
+}
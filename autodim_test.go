@@ -0,0 +1,23 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func TestAutoDimIsNotSupported(t *testing.T) {
+	var calls int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	autoDim, err := c.GetAutoDim(context.Background(), "device")
+	assert.Assert(t, errors.Is(err, ErrNotSupported), "expected not supported, got %v", err)
+	assert.Assert(t, autoDim == nil)
+	err = c.SetAutoDim(context.Background(), "device", true)
+	assert.Assert(t, errors.Is(err, ErrNotSupported), "expected not supported, got %v", err)
+	assert.Equal(t, calls, 0)
+}
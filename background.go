@@ -0,0 +1,47 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithTimeout bounds the calls made through the no-context shortcuts like ListDevicesBg, including any retries
+func WithTimeout(d time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("timeout must be positive, got %s", d)
+		}
+		c.defaultTimeout = d
+		return nil
+	}
+}
+
+// backgroundContext returns a context for the no-context shortcuts, bounded by the timeout configured WithTimeout
+func (c *Client) backgroundContext() (context.Context, context.CancelFunc) {
+	if c.defaultTimeout > 0 {
+		return context.WithTimeout(context.Background(), c.defaultTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// ListDevicesBg is ListDevices without a context, for scripts
+func (c *Client) ListDevicesBg() ([]Device, error) {
+	ctx, cancel := c.backgroundContext()
+	defer cancel()
+	return c.ListDevices(ctx)
+}
+
+// GetBg is Get without a context, for scripts
+func (c *Client) GetBg(deviceID string) (*DeviceDetails, error) {
+	ctx, cancel := c.backgroundContext()
+	defer cancel()
+	return c.Get(ctx, deviceID)
+}
+
+// UpdateBg is Update without a context, for scripts
+func (c *Client) UpdateBg(deviceID string, r UpdateRequest) error {
+	ctx, cancel := c.backgroundContext()
+	defer cancel()
+	return c.Update(ctx, deviceID, r)
+}
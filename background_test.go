@@ -0,0 +1,45 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackgroundShortcuts(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/devices":
+			json.NewEncoder(w).Encode([]Device{{ID: "device"}})
+		default:
+			var details DeviceDetails
+			details.About.Model = "DP999NA"
+			json.NewEncoder(w).Encode(details)
+		}
+	}))
+
+	devices, err := c.ListDevicesBg()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, devices, []Device{{ID: "device"}})
+
+	details, err := c.GetBg("device")
+	assert.NilError(t, err)
+	assert.Equal(t, details.About.Model, "DP999NA")
+
+	assert.NilError(t, c.UpdateBg("device", UpdateRequest{}))
+}
+
+func TestBackgroundShortcutsHonorTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}), WithTimeout(20*time.Millisecond))
+
+	_, err := c.ListDevicesBg()
+	assert.Assert(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got %v", err)
+}
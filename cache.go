@@ -0,0 +1,44 @@
+package sleepme
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DeviceCache serves the devices of an account from memory, listing them again once they are older than its TTL.
+// It is safe for concurrent use; concurrent calls while the list is stale wait for a single refresh
+type DeviceCache struct {
+	c   *Client
+	ttl time.Duration
+
+	mu        sync.Mutex
+	devices   []Device
+	fetchedAt time.Time
+}
+
+// CachedDevices returns a cache of ListDevices. Devices added to or removed from the account show up
+// once the cached list is older than ttl. A ttl of zero or less lists the devices on every call
+func (c *Client) CachedDevices(ttl time.Duration) *DeviceCache {
+	return &DeviceCache{c: c, ttl: ttl}
+}
+
+// List returns the cached devices, listing them first if the cache is empty or stale.
+// A failed refresh returns its error, and the next call tries again
+func (dc *DeviceCache) List(ctx context.Context) ([]Device, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	now := dc.c.clock.Now()
+	if dc.devices == nil || now.Sub(dc.fetchedAt) >= dc.ttl {
+		devices, err := dc.c.ListDevices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if devices == nil {
+			devices = []Device{}
+		}
+		dc.devices, dc.fetchedAt = devices, now
+	}
+	return append([]Device(nil), dc.devices...), nil
+}
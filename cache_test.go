@@ -0,0 +1,79 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCachedDevicesRefreshesAfterTTL(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		calls   int
+		devices = []Device{{ID: "a"}}
+	)
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		json.NewEncoder(w).Encode(devices)
+	}), WithClock(clock))
+	cache := c.CachedDevices(time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list, err := cache.List(context.Background())
+			assert.Check(t, err)
+			assert.Check(t, len(list) == 1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, calls, 1)
+
+	mu.Lock()
+	devices = append(devices, Device{ID: "b"})
+	mu.Unlock()
+	clock.Advance(59 * time.Second)
+	list, err := cache.List(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, list, []Device{{ID: "a"}})
+
+	clock.Advance(time.Second)
+	list, err = cache.List(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, list, []Device{{ID: "a"}, {ID: "b"}})
+	assert.Equal(t, calls, 2)
+}
+
+func TestCachedDevicesRetriesFailedRefresh(t *testing.T) {
+	var fail bool
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode([]Device{{ID: "a"}})
+	}), WithClock(clock))
+	cache := c.CachedDevices(time.Minute)
+
+	_, err := cache.List(context.Background())
+	assert.NilError(t, err)
+	fail = true
+	clock.Advance(time.Minute)
+	_, err = cache.List(context.Background())
+	assert.ErrorContains(t, err, "expected 200, got 500")
+
+	fail = false
+	list, err := cache.List(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, list, []Device{{ID: "a"}})
+}
@@ -0,0 +1,109 @@
+package sleepme
+
+import (
+	"strings"
+	"sync"
+)
+
+// Capabilities describes what a device supports, e.g. to decide which controls a UI shows
+type Capabilities struct {
+	MinTemperatureF    float64
+	MaxTemperatureF    float64
+	MinTemperatureC    float64
+	MaxTemperatureC    float64
+	SupportsBrightness bool
+	SupportsSchedules  bool
+	SupportsLock       bool
+	SupportsAutoDim    bool
+	// SupportsHalfDegreeF is set for devices accepting Fahrenheit set temperatures in steps of 0.5 degrees
+	SupportsHalfDegreeF bool
+}
+
+// CapabilityRule assigns capabilities to devices whose model starts with ModelPrefix,
+// optionally restricted to firmware of at least MinFirmware
+type CapabilityRule struct {
+	ModelPrefix  string
+	MinFirmware  string
+	Capabilities Capabilities
+}
+
+// CapabilityTable looks up capabilities by model and firmware. The first matching rule wins,
+// so more specific rules must come before general ones
+type CapabilityTable struct {
+	Rules []CapabilityRule
+	// Fallback applies to devices no rule matches
+	Fallback Capabilities
+}
+
+// DefaultCapabilities is the table used by DeviceDetails.Capabilities. It can be replaced or extended
+// as sleep.me releases new models or firmware.
+// The API has no endpoints for schedules, auto-dim or a child lock, so no model supports them by default.
+// No released firmware is known to accept half degrees Fahrenheit yet
+var DefaultCapabilities = CapabilityTable{
+	Rules: []CapabilityRule{
+		{
+			ModelPrefix: "DP",
+			Capabilities: Capabilities{
+				MinTemperatureF:    MinTemperatureF,
+				MaxTemperatureF:    MaxTemperatureF,
+				MinTemperatureC:    MinTemperatureC,
+				MaxTemperatureC:    MaxTemperatureC,
+				SupportsBrightness: true,
+			},
+		},
+	},
+	Fallback: Capabilities{
+		MinTemperatureF: MinTemperatureF,
+		MaxTemperatureF: MaxTemperatureF,
+		MinTemperatureC: MinTemperatureC,
+		MaxTemperatureC: MaxTemperatureC,
+	},
+}
+
+// Capabilities returns the capabilities of the first rule matching the model and firmware of details.
+// Rules with a MinFirmware never match devices reporting an unparseable firmware version
+func (t CapabilityTable) Capabilities(details *DeviceDetails) Capabilities {
+	firmware, firmwareErr := details.FirmwareVersionParsed()
+	for _, rule := range t.Rules {
+		if !strings.HasPrefix(details.About.Model, rule.ModelPrefix) {
+			continue
+		}
+		if rule.MinFirmware != "" {
+			min, err := ParseFirmwareVersion(rule.MinFirmware)
+			if err != nil || firmwareErr != nil || firmware.Compare(min) < 0 {
+				continue
+			}
+		}
+		return rule.Capabilities
+	}
+	return t.Fallback
+}
+
+// Capabilities looks up the capabilities of the device in DefaultCapabilities
+func (d *DeviceDetails) Capabilities() Capabilities {
+	return DefaultCapabilities.Capabilities(d)
+}
+
+// capabilityCache remembers the capabilities of every device fetched with Get, so writes can adapt
+// to the device without fetching it first
+type capabilityCache struct {
+	mu      sync.Mutex
+	devices map[string]Capabilities
+}
+
+func (cc *capabilityCache) remember(deviceID string, details *DeviceDetails) {
+	capabilities := details.Capabilities()
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.devices == nil {
+		cc.devices = map[string]Capabilities{}
+	}
+	cc.devices[deviceID] = capabilities
+}
+
+func (cc *capabilityCache) lookup(deviceID string) (Capabilities, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	capabilities, ok := cc.devices[deviceID]
+	return capabilities, ok
+}
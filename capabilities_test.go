@@ -0,0 +1,39 @@
+package sleepme
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func detailsOf(model, firmware string) *DeviceDetails {
+	var details DeviceDetails
+	details.About.Model = model
+	details.About.FirmwareVersion = firmware
+	return &details
+}
+
+func TestDefaultCapabilities(t *testing.T) {
+	dockPro := detailsOf("DP999NA", "5.39.2153").Capabilities()
+	assert.Assert(t, dockPro.SupportsBrightness)
+	assert.Assert(t, !dockPro.SupportsSchedules)
+	assert.Equal(t, dockPro.MinTemperatureF, float64(55))
+	assert.Equal(t, dockPro.MaxTemperatureF, float64(115))
+
+	unknown := detailsOf("XX1", "1.0").Capabilities()
+	assert.DeepEqual(t, unknown, DefaultCapabilities.Fallback)
+}
+
+func TestCapabilityTableFirmwareRules(t *testing.T) {
+	table := CapabilityTable{
+		Rules: []CapabilityRule{
+			{ModelPrefix: "DP", MinFirmware: "6.0", Capabilities: Capabilities{SupportsBrightness: true, SupportsSchedules: true}},
+			{ModelPrefix: "DP", Capabilities: Capabilities{SupportsBrightness: true}},
+		},
+		Fallback: Capabilities{},
+	}
+
+	assert.DeepEqual(t, table.Capabilities(detailsOf("DP999NA", "6.1")), Capabilities{SupportsBrightness: true, SupportsSchedules: true})
+	assert.DeepEqual(t, table.Capabilities(detailsOf("DP999NA", "5.10")), Capabilities{SupportsBrightness: true})
+	assert.DeepEqual(t, table.Capabilities(detailsOf("DP999NA", "unknown")), Capabilities{SupportsBrightness: true})
+	assert.DeepEqual(t, table.Capabilities(detailsOf("OOLER", "6.1")), Capabilities{})
+}
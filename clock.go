@@ -0,0 +1,46 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Clock tells the time for watchers, retries and schedules. It can be replaced WithClock, e.g. in tests
+type Clock interface {
+	Now() time.Time
+	// After waits for d to pass and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// sleepCtx waits for d to pass on the clock of the client, returning ctx.Err() as soon as ctx is done.
+// Polling loops wait with it, so none of them blocks past the cancellation of its context
+func (c *Client) sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(d):
+		return nil
+	}
+}
+
+// WithClock replaces the wall clock used by the client
+func WithClock(clock Clock) func(*Client) error {
+	return func(c *Client) error {
+		if clock == nil {
+			return errors.New("clock must not be nil")
+		}
+		c.clock = clock
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithCoalesce batches updates per device: the first Update to a device opens a window,
+// and every Update to that device within the window is merged into a single request sent when it closes.
+// Later calls overwrite fields set by earlier ones. Each Update blocks until the merged request was sent
+// and returns its result. Pending updates are sent right away on Flush and Close
+func WithCoalesce(window time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if window <= 0 {
+			return fmt.Errorf("coalesce window must be positive, got %s", window)
+		}
+		c.coalescer = &coalescer{
+			window: window,
+			// read when the window opens, so WithClock may come after WithCoalesce
+			after:   func(d time.Duration) <-chan time.Time { return c.clock.After(d) },
+			send:    c.update,
+			pending: map[string]*pendingUpdate{},
+		}
+		return nil
+	}
+}
+
+// Flush sends all pending SetTemperatureDebounced values and then all pending coalesced updates
+// right away using ctx, returning the first error encountered. Callers blocked in Update or
+// SetTemperatureDebounced receive the result of their request
+func (c *Client) Flush(ctx context.Context) error {
+	// debounced values are sent through Update, which merges them into pending coalesced updates
+	err := c.debouncer.flush(ctx, c)
+	if c.coalescer != nil {
+		if cerr := c.coalescer.flush(ctx); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Close shuts the client down in a fixed order: first every pending schedule is cancelled, waiting for
+// their goroutines to return, which also cancels a scheduled update being applied at that moment.
+// Then all pending debounced and coalesced updates are sent, see Flush
+func (c *Client) Close() error {
+	c.schedules.cancelAll()
+	return c.Flush(context.Background())
+}
+
+type coalescer struct {
+	window time.Duration
+	after  func(d time.Duration) <-chan time.Time
+	send   func(ctx context.Context, deviceID string, r UpdateRequest) error
+
+	mu      sync.Mutex
+	pending map[string]*pendingUpdate
+}
+
+type pendingUpdate struct {
+	req UpdateRequest
+	// closed once the request was taken, ending the wait for the window to close
+	taken   chan struct{}
+	waiters []chan error
+}
+
+func (co *coalescer) update(ctx context.Context, deviceID string, r UpdateRequest) error {
+	done := make(chan error, 1)
+
+	co.mu.Lock()
+	p, ok := co.pending[deviceID]
+	if !ok {
+		p = &pendingUpdate{taken: make(chan struct{})}
+		closed := co.after(co.window)
+		go func() {
+			select {
+			case <-closed:
+				co.sendPending(context.Background(), deviceID, p)
+			case <-p.taken:
+			}
+		}()
+		co.pending[deviceID] = p
+	}
+	p.req = p.req.merge(r)
+	p.waiters = append(p.waiters, done)
+	co.mu.Unlock()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendPending sends p using ctx unless it was already taken by a concurrent flush.
+// The request is detached from the contexts of the individual callers, as it serves all of them
+func (co *coalescer) sendPending(ctx context.Context, deviceID string, p *pendingUpdate) error {
+	co.mu.Lock()
+	if co.pending[deviceID] != p {
+		co.mu.Unlock()
+		return nil
+	}
+	delete(co.pending, deviceID)
+	close(p.taken)
+	co.mu.Unlock()
+
+	err := co.send(ctx, deviceID, p.req)
+	for _, waiter := range p.waiters {
+		waiter <- err
+	}
+	return err
+}
+
+// flush sends all pending updates, returning the first error encountered
+func (co *coalescer) flush(ctx context.Context) error {
+	co.mu.Lock()
+	pending := make(map[string]*pendingUpdate, len(co.pending))
+	for deviceID, p := range co.pending {
+		pending[deviceID] = p
+	}
+	co.mu.Unlock()
+
+	var firstErr error
+	for deviceID, p := range pending {
+		if err := co.sendPending(ctx, deviceID, p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// merge returns a copy of r with every field set on o overwriting the value of r.
+// A set temperature in either unit replaces both of r, so the merged request can't contain contradicting setpoints
+func (r UpdateRequest) merge(o UpdateRequest) UpdateRequest {
+	if o.ThermalControlStatus != nil {
+		r.ThermalControlStatus = o.ThermalControlStatus
+	}
+	if o.SetTemperatureF != nil || o.SetTemperatureC != nil {
+		r.SetTemperatureF, r.SetTemperatureC = o.SetTemperatureF, o.SetTemperatureC
+	}
+	if o.DisplayTemperatureUnit != nil {
+		r.DisplayTemperatureUnit = o.DisplayTemperatureUnit
+	}
+	if o.TimeZone != nil {
+		r.TimeZone = o.TimeZone
+	}
+	if o.BrightnessLevel != nil {
+		r.BrightnessLevel = o.BrightnessLevel
+	}
+	return r
+}
@@ -0,0 +1,171 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordUpdates returns a handler storing the decoded body of every request it receives
+func recordUpdates(mu *sync.Mutex, bodies *[]map[string]interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		*bodies = append(*bodies, body)
+		mu.Unlock()
+	})
+}
+
+func TestCoalesceMergesUpdates(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []map[string]interface{}
+	)
+	c := newTestClient(t, recordUpdates(&mu, &bodies), WithCoalesce(50*time.Millisecond))
+
+	first, second := float64(60), float64(70)
+	unit := DisplayTemperatureUnitC
+	var wg sync.WaitGroup
+	for _, r := range []UpdateRequest{
+		{SetTemperatureF: &first, DisplayTemperatureUnit: &unit},
+		{SetTemperatureF: &second},
+	} {
+		wg.Add(1)
+		go func(r UpdateRequest) {
+			defer wg.Done()
+			assert.Check(t, c.Update(context.Background(), "device", r))
+		}(r)
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	assert.DeepEqual(t, bodies, []map[string]interface{}{
+		{"set_temperature_f": float64(70), "display_temperature_unit": "c"},
+	})
+}
+
+func TestCloseFlushesCoalescedUpdates(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []map[string]interface{}
+	)
+	c := newTestClient(t, recordUpdates(&mu, &bodies), WithCoalesce(time.Hour))
+
+	temperature := float64(65)
+	done := make(chan error)
+	go func() {
+		done <- c.Update(context.Background(), "device", UpdateRequest{SetTemperatureF: &temperature})
+	}()
+	for {
+		c.coalescer.mu.Lock()
+		n := len(c.coalescer.pending)
+		c.coalescer.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.NilError(t, c.Close())
+	assert.NilError(t, <-done)
+	assert.DeepEqual(t, bodies, []map[string]interface{}{
+		{"set_temperature_f": float64(65)},
+	})
+}
+
+func TestWithCoalesceRejectsInvalidWindow(t *testing.T) {
+	_, err := New("token", WithCoalesce(0))
+	assert.ErrorContains(t, err, "coalesce window must be positive")
+}
+
+func TestFlushSendsCoalescedUpdates(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []map[string]interface{}
+	)
+	c := newTestClient(t, recordUpdates(&mu, &bodies), WithCoalesce(time.Hour))
+	assert.NilError(t, c.Flush(context.Background()))
+
+	for _, temperature := range []float64{65, 70} {
+		temperature := temperature
+		done := make(chan error)
+		go func() {
+			done <- c.Update(context.Background(), "device", UpdateRequest{SetTemperatureF: &temperature})
+		}()
+		for {
+			c.coalescer.mu.Lock()
+			n := len(c.coalescer.pending)
+			c.coalescer.mu.Unlock()
+			if n > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		assert.NilError(t, c.Flush(context.Background()))
+		assert.NilError(t, <-done)
+	}
+	assert.DeepEqual(t, bodies, []map[string]interface{}{
+		{"set_temperature_f": float64(65)},
+		{"set_temperature_f": float64(70)},
+	})
+}
+
+func TestCoalesceLaterSetpointReplacesOtherUnit(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []map[string]interface{}
+	)
+	c := newTestClient(t, recordUpdates(&mu, &bodies), WithCoalesce(time.Hour))
+
+	f, celsius := float64(70), float64(18)
+	status := ThermalControlStatusActive
+	done := make(chan error, 2)
+	go func() {
+		done <- c.Update(context.Background(), "device", UpdateRequest{SetTemperatureF: &f, ThermalControlStatus: &status})
+	}()
+	time.Sleep(5 * time.Millisecond)
+	go func() { done <- c.Update(context.Background(), "device", UpdateRequest{SetTemperatureC: &celsius}) }()
+	time.Sleep(5 * time.Millisecond)
+	assert.NilError(t, c.Flush(context.Background()))
+	assert.NilError(t, <-done)
+	assert.NilError(t, <-done)
+
+	assert.DeepEqual(t, bodies, []map[string]interface{}{
+		{"set_temperature_c": float64(18), "thermal_control_status": "active"},
+	})
+}
+
+func TestCoalesceWindowUsesClock(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []map[string]interface{}
+	)
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, recordUpdates(&mu, &bodies), WithCoalesce(time.Minute), WithClock(clock))
+
+	temperature := float64(65)
+	done := make(chan error, 1)
+	go func() { done <- c.Update(context.Background(), "device", UpdateRequest{SetTemperatureF: &temperature}) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("sent before the window closed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	select {
+	case err := <-done:
+		assert.NilError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("not sent once the window closed")
+	}
+	assert.DeepEqual(t, bodies, []map[string]interface{}{{"set_temperature_f": float64(65)}})
+}
@@ -0,0 +1,80 @@
+package sleepme
+
+import "context"
+
+// DeviceConfig contains the settings of a Dock Pro which can be changed via the API.
+// It can be stored as JSON, e.g. to keep presets like "summer" and "winter"
+type DeviceConfig struct {
+	ThermalControlStatus   ThermalControlStatus   `json:"thermal_control_status"`
+	SetTemperatureF        float64                `json:"set_temperature_f"`
+	SetTemperatureC        float64                `json:"set_temperature_c"`
+	DisplayTemperatureUnit DisplayTemperatureUnit `json:"display_temperature_unit"`
+	TimeZone               string                 `json:"time_zone"`
+	BrightnessLevel        int                    `json:"brightness_level"`
+}
+
+// ExportConfig captures the current settings of a Dock Pro
+func (c *Client) ExportConfig(ctx context.Context, deviceID string) (DeviceConfig, error) {
+	details, err := c.Get(ctx, deviceID)
+	if err != nil {
+		return DeviceConfig{}, err
+	}
+	return configFromDetails(details), nil
+}
+
+// configFromDetails extracts the settings which can be changed via the API
+func configFromDetails(details *DeviceDetails) DeviceConfig {
+	return DeviceConfig{
+		ThermalControlStatus:   ThermalControlStatus(details.Control.ThermalControlStatus),
+		SetTemperatureF:        details.Control.SetTemperatureF,
+		SetTemperatureC:        float64(details.Control.SetTemperatureC),
+		DisplayTemperatureUnit: DisplayTemperatureUnit(details.Control.DisplayTemperatureUnit),
+		TimeZone:               details.Control.TimeZone,
+		BrightnessLevel:        details.Control.BrightnessLevel,
+	}
+}
+
+// ApplyConfig reconfigures a Dock Pro to match cfg in a single Update. Empty or zero fields, like those of a
+// device which didn't report them, and statuses or units unknown to this package are left unchanged; turn the
+// display off with UpdateRequest.BrightnessLevel instead. The remaining fields are validated even by clients created WithSkipValidation, as configs are
+// usually loaded from files
+func (c *Client) ApplyConfig(ctx context.Context, deviceID string, cfg DeviceConfig) error {
+	r := cfg.updateRequest()
+	if err := r.validate(c.temperaturePolicy == PolicyError); err != nil {
+		return err
+	}
+	if r == (UpdateRequest{}) {
+		return nil
+	}
+	return c.Update(ctx, deviceID, r)
+}
+
+// Validate checks the config against the constraints of the API, see UpdateRequest.Validate
+func (cfg DeviceConfig) Validate() error {
+	return cfg.updateRequest().Validate()
+}
+
+// updateRequest converts the config into an update of its non-empty fields with known values.
+// Only the set temperature matching the display unit is sent, so the two can't contradict each other
+func (cfg DeviceConfig) updateRequest() UpdateRequest {
+	var r UpdateRequest
+	if cfg.BrightnessLevel != 0 {
+		r.BrightnessLevel = &cfg.BrightnessLevel
+	}
+	if cfg.ThermalControlStatus.valid() {
+		r.ThermalControlStatus = &cfg.ThermalControlStatus
+	}
+	if cfg.DisplayTemperatureUnit.valid() {
+		r.DisplayTemperatureUnit = &cfg.DisplayTemperatureUnit
+	}
+	if cfg.TimeZone != "" {
+		r.TimeZone = &cfg.TimeZone
+	}
+	switch {
+	case cfg.DisplayTemperatureUnit == DisplayTemperatureUnitC && cfg.SetTemperatureC != 0:
+		r.SetTemperatureC = &cfg.SetTemperatureC
+	case cfg.DisplayTemperatureUnit != DisplayTemperatureUnitC && cfg.SetTemperatureF != 0:
+		r.SetTemperatureF = &cfg.SetTemperatureF
+	}
+	return r
+}
@@ -0,0 +1,127 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func testDeviceDetails() DeviceDetails {
+	var details DeviceDetails
+	details.Control.ThermalControlStatus = "active"
+	details.Control.SetTemperatureF = 68
+	details.Control.SetTemperatureC = 20
+	details.Control.DisplayTemperatureUnit = "c"
+	details.Control.TimeZone = "Europe/Berlin"
+	details.Control.BrightnessLevel = 40
+	return details
+}
+
+func TestExportAndApplyConfig(t *testing.T) {
+	var updates []map[string]interface{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			updates = append(updates, body)
+			return
+		}
+		json.NewEncoder(w).Encode(testDeviceDetails())
+	}))
+
+	cfg, err := c.ExportConfig(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cfg, DeviceConfig{
+		ThermalControlStatus:   ThermalControlStatusActive,
+		SetTemperatureF:        68,
+		SetTemperatureC:        20,
+		DisplayTemperatureUnit: DisplayTemperatureUnitC,
+		TimeZone:               "Europe/Berlin",
+		BrightnessLevel:        40,
+	})
+
+	// presets are stored as JSON
+	bs, err := json.Marshal(cfg)
+	assert.NilError(t, err)
+	var preset DeviceConfig
+	assert.NilError(t, json.Unmarshal(bs, &preset))
+
+	assert.NilError(t, c.ApplyConfig(context.Background(), "other", preset))
+	assert.DeepEqual(t, updates, []map[string]interface{}{{
+		"thermal_control_status":   "active",
+		"set_temperature_c":        float64(20),
+		"display_temperature_unit": "c",
+		"time_zone":                "Europe/Berlin",
+		"brightness_level":         float64(40),
+	}})
+}
+
+func TestApplyConfigValidates(t *testing.T) {
+	var calls int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}), WithSkipValidation())
+
+	err := c.ApplyConfig(context.Background(), "device", DeviceConfig{TimeZone: "Mars/Olympus_Mons", BrightnessLevel: 200})
+	var verr *ValidationError
+	assert.Assert(t, errors.As(err, &verr), "expected a validation error, got %v", err)
+	assert.Equal(t, calls, 0)
+}
+
+func TestDeviceConfigRoundTripsAnyDevice(t *testing.T) {
+	devices := map[string]DeviceDetails{"active": testDeviceDetails()}
+	var unknown DeviceDetails
+	unknown.Control.ThermalControlStatus = "boost"
+	devices["unknown"] = unknown
+	devices["empty"] = DeviceDetails{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(devices[r.URL.Path[len("/devices/"):]])
+	}))
+
+	for id := range devices {
+		cfg, err := c.ExportConfig(context.Background(), id)
+		assert.NilError(t, err)
+		bs, err := json.Marshal(cfg)
+		assert.NilError(t, err, "device %s", id)
+		var preset DeviceConfig
+		assert.NilError(t, json.Unmarshal(bs, &preset))
+		assert.DeepEqual(t, preset, cfg)
+	}
+}
+
+func TestApplyConfigSkipsEmptyFields(t *testing.T) {
+	var unknown DeviceDetails
+	unknown.Control.ThermalControlStatus = "boost"
+	unknown.Control.DisplayTemperatureUnit = "k"
+	unknown.Control.BrightnessLevel = 10
+	for _, tc := range []struct {
+		details DeviceDetails
+		want    []map[string]interface{}
+	}{
+		{details: DeviceDetails{}},
+		{details: unknown, want: []map[string]interface{}{{"brightness_level": float64(10)}}},
+	} {
+		var updates []map[string]interface{}
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PATCH" {
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				updates = append(updates, body)
+				return
+			}
+			json.NewEncoder(w).Encode(tc.details)
+		}))
+
+		cfg, err := c.ExportConfig(context.Background(), "device")
+		assert.NilError(t, err)
+		bs, err := json.Marshal(cfg)
+		assert.NilError(t, err)
+		var preset DeviceConfig
+		assert.NilError(t, json.Unmarshal(bs, &preset))
+		assert.NilError(t, c.ApplyConfig(context.Background(), "device", preset))
+		assert.DeepEqual(t, updates, tc.want)
+	}
+}
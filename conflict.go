@@ -0,0 +1,64 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrConflict is returned by UpdateIfUnchanged when the device changed since it was read
+var ErrConflict = errors.New("device changed since it was read")
+
+// UpdateIfUnchanged is Update, but only applies r if the fields it sets still have the values from expected,
+// e.g. the details an app showed its user before they made a change. Otherwise it returns ErrConflict, so
+// the app can read the device again instead of overwriting a change made by someone else.
+// The device is read again right before the update, and writes of this client can't happen in between;
+// the API offers no conditional writes though, so a change made by another app in that moment is still
+// overwritten. The update is sent right away, even by clients created WithCoalesce
+func (c *Client) UpdateIfUnchanged(ctx context.Context, deviceID string, expected *DeviceDetails, r UpdateRequest) error {
+	if expected == nil {
+		return errors.New("expected details must not be nil")
+	}
+	r, err := c.prepareUpdate(deviceID, r)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := c.writeLocks.lock(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := c.Get(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if changed := changedFields(r, expected, current); len(changed) > 0 {
+		return fmt.Errorf("%w: %s", ErrConflict, strings.Join(changed, ", "))
+	}
+	return c.patch(ctx, deviceID, r)
+}
+
+// changedFields lists the fields set by r which differ between expected and current
+func changedFields(r UpdateRequest, expected, current *DeviceDetails) []string {
+	e, a := expected.Control, current.Control
+	var changed []string
+	if r.ThermalControlStatus != nil && e.ThermalControlStatus != a.ThermalControlStatus {
+		changed = append(changed, "thermal_control_status")
+	}
+	if (r.SetTemperatureF != nil || r.SetTemperatureC != nil) && (e.SetTemperatureF != a.SetTemperatureF || e.SetTemperatureC != a.SetTemperatureC) {
+		changed = append(changed, "set temperature")
+	}
+	if r.DisplayTemperatureUnit != nil && e.DisplayTemperatureUnit != a.DisplayTemperatureUnit {
+		changed = append(changed, "display_temperature_unit")
+	}
+	if r.TimeZone != nil && e.TimeZone != a.TimeZone {
+		changed = append(changed, "time_zone")
+	}
+	if r.BrightnessLevel != nil && e.BrightnessLevel != a.BrightnessLevel {
+		changed = append(changed, "brightness_level")
+	}
+	return changed
+}
@@ -0,0 +1,72 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// deviceState serves a device whose details can be changed behind the back of the client
+type deviceState struct {
+	mu      sync.Mutex
+	details DeviceDetails
+	patches int
+}
+
+func (s *deviceState) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == "PATCH" {
+		s.patches++
+		var req UpdateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.BrightnessLevel != nil {
+			s.details.Control.BrightnessLevel = *req.BrightnessLevel
+		}
+		if req.SetTemperatureF != nil {
+			s.details.Control.SetTemperatureF = *req.SetTemperatureF
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(s.details)
+}
+
+func TestUpdateIfUnchanged(t *testing.T) {
+	state := &deviceState{}
+	state.details.Control.DisplayTemperatureUnit = "f"
+	state.details.Control.SetTemperatureF = 70
+	state.details.Control.BrightnessLevel = 50
+	c := newTestClient(t, state)
+
+	expected, err := c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+	f := float64(72)
+	assert.NilError(t, c.UpdateIfUnchanged(context.Background(), "device", expected, UpdateRequest{SetTemperatureF: &f}))
+	assert.Equal(t, state.patches, 1)
+
+	// another app changes the brightness
+	state.mu.Lock()
+	state.details.Control.BrightnessLevel = 20
+	state.mu.Unlock()
+
+	level := 80
+	err = c.UpdateIfUnchanged(context.Background(), "device", expected, UpdateRequest{BrightnessLevel: &level})
+	assert.Assert(t, errors.Is(err, ErrConflict), "expected a conflict, got %v", err)
+	assert.ErrorContains(t, err, "brightness_level")
+	// the temperature was changed by this client, so expected is stale for it too
+	err = c.UpdateIfUnchanged(context.Background(), "device", expected, UpdateRequest{SetTemperatureF: &f})
+	assert.Assert(t, errors.Is(err, ErrConflict), "expected a conflict, got %v", err)
+	assert.Equal(t, state.patches, 1)
+	assert.Equal(t, state.details.Control.BrightnessLevel, 20)
+
+	current, err := c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.NilError(t, c.UpdateIfUnchanged(context.Background(), "device", current, UpdateRequest{BrightnessLevel: &level}))
+	assert.Equal(t, state.details.Control.BrightnessLevel, 80)
+
+	assert.ErrorContains(t, c.UpdateIfUnchanged(context.Background(), "device", nil, UpdateRequest{BrightnessLevel: &level}), "must not be nil")
+}
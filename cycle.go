@@ -0,0 +1,83 @@
+package sleepme
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CycleDetector detects devices turned on and off rapidly, e.g. by a misconfigured automation,
+// which wears out the hardware
+type CycleDetector struct {
+	maxTransitions int
+	window         time.Duration
+	onCycling      func(deviceID string)
+
+	mu      sync.Mutex
+	devices map[string]*cycleState
+}
+
+type cycleState struct {
+	on          bool
+	transitions []time.Time
+}
+
+// NewCycleDetector reports devices switching between on and off more than maxTransitions times within window.
+// onCycling, if not nil, is called for every such switch
+func NewCycleDetector(maxTransitions int, window time.Duration, onCycling func(deviceID string)) (*CycleDetector, error) {
+	if maxTransitions < 1 {
+		return nil, fmt.Errorf("max transitions must be at least 1, got %d", maxTransitions)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window must be positive, got %s", window)
+	}
+	return &CycleDetector{
+		maxTransitions: maxTransitions,
+		window:         window,
+		onCycling:      onCycling,
+		devices:        map[string]*cycleState{},
+	}, nil
+}
+
+// WithCycleDetector records every TurnOn and TurnOff of the client in d
+func WithCycleDetector(d *CycleDetector) func(*Client) error {
+	return func(c *Client) error {
+		if d == nil {
+			return errors.New("cycle detector must not be nil")
+		}
+		c.cycleDetector = d
+		return nil
+	}
+}
+
+// Record notes that a device was turned on or off at the given time, and reports whether it is cycling.
+// Turning a device on which is already on is not a transition
+func (d *CycleDetector) Record(deviceID string, on bool, at time.Time) bool {
+	d.mu.Lock()
+	state, ok := d.devices[deviceID]
+	if !ok {
+		d.devices[deviceID] = &cycleState{on: on}
+		d.mu.Unlock()
+		return false
+	}
+	if state.on == on {
+		d.mu.Unlock()
+		return false
+	}
+	state.on = on
+	kept := state.transitions[:0]
+	for _, transition := range state.transitions {
+		if at.Sub(transition) < d.window {
+			kept = append(kept, transition)
+		}
+	}
+	state.transitions = append(kept, at)
+	cycling := len(state.transitions) > d.maxTransitions
+	d.mu.Unlock()
+
+	if cycling && d.onCycling != nil {
+		d.onCycling(deviceID)
+	}
+	return cycling
+}
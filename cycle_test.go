@@ -0,0 +1,68 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCycleDetector(t *testing.T) {
+	var cycling []string
+	d, err := NewCycleDetector(3, time.Minute, func(deviceID string) {
+		cycling = append(cycling, deviceID)
+	})
+	assert.NilError(t, err)
+
+	start := time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC)
+	assert.Assert(t, !d.Record("device", true, start))
+	// repeating the current state is not a transition
+	assert.Assert(t, !d.Record("device", true, start))
+	for i, on := range []bool{false, true, false} {
+		assert.Assert(t, !d.Record("device", on, start.Add(time.Duration(i+1)*time.Second)))
+	}
+	assert.Assert(t, d.Record("device", true, start.Add(4*time.Second)))
+	assert.Assert(t, !d.Record("other", false, start.Add(4*time.Second)))
+	assert.DeepEqual(t, cycling, []string{"device"})
+
+	// transitions older than the window are forgotten
+	assert.Assert(t, !d.Record("device", false, start.Add(2*time.Minute)))
+
+	_, err = NewCycleDetector(0, time.Minute, nil)
+	assert.ErrorContains(t, err, "at least 1")
+	_, err = NewCycleDetector(1, 0, nil)
+	assert.ErrorContains(t, err, "must be positive")
+}
+
+func TestTurnOnOffRecordsCycles(t *testing.T) {
+	cycling := make(chan string, 1)
+	d, err := NewCycleDetector(2, time.Minute, func(deviceID string) {
+		cycling <- deviceID
+	})
+	assert.NilError(t, err)
+	var statuses []string
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, recordStatuses(&statuses), WithClock(clock), WithCycleDetector(d))
+
+	ctx := context.Background()
+	assert.NilError(t, c.TurnOn(ctx, "device"))
+	assert.NilError(t, c.TurnOff(ctx, "device"))
+	assert.NilError(t, c.TurnOn(ctx, "device"))
+	assert.Equal(t, len(cycling), 0)
+	assert.NilError(t, c.TurnOff(ctx, "device"))
+	assert.Equal(t, <-cycling, "device")
+	assert.DeepEqual(t, statuses, []string{"active", "standby", "active", "standby"})
+}
+
+// recordStatuses returns a handler storing the thermal control status of every update
+func recordStatuses(statuses *[]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ThermalControlStatus string `json:"thermal_control_status"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		*statuses = append(*statuses, body.ThermalControlStatus)
+	})
+}
@@ -0,0 +1,115 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// debouncer holds the pending SetTemperatureDebounced values per device
+type debouncer struct {
+	mu      sync.Mutex
+	pending map[string]*debounced
+}
+
+type debounced struct {
+	f        float64
+	deadline time.Time
+	waiters  []chan error
+	stop     chan struct{}
+}
+
+// SetTemperatureDebounced is SetTemperatureF for interactive controls like sliders: the value is only sent
+// once no other value was set for the device for quiet. Values superseded within that time are never sent,
+// but the last one always is, even if ctx is cancelled before, and at the latest on Flush or Close.
+// The call blocks until the last value was sent and returns its result, or until ctx is done
+func (c *Client) SetTemperatureDebounced(ctx context.Context, deviceID string, f float64, quiet time.Duration) error {
+	if quiet <= 0 {
+		return fmt.Errorf("quiet period must be positive, got %s", quiet)
+	}
+	r, err := c.prepareUpdate(deviceID, UpdateRequest{SetTemperatureF: &f})
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	d := &c.debouncer
+	d.mu.Lock()
+	if d.pending == nil {
+		d.pending = map[string]*debounced{}
+	}
+	p, ok := d.pending[deviceID]
+	if !ok {
+		p = &debounced{stop: make(chan struct{})}
+		d.pending[deviceID] = p
+		go c.sendDebounced(deviceID, p)
+	}
+	p.f = *r.SetTemperatureF
+	p.deadline = c.clock.Now().Add(quiet)
+	p.waiters = append(p.waiters, done)
+	d.mu.Unlock()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendDebounced waits until the deadline of p passed without being moved, and then sends its value
+func (c *Client) sendDebounced(deviceID string, p *debounced) {
+	d := &c.debouncer
+	for {
+		d.mu.Lock()
+		remaining := p.deadline.Sub(c.clock.Now())
+		d.mu.Unlock()
+		if remaining <= 0 {
+			break
+		}
+		select {
+		case <-c.clock.After(remaining):
+		case <-p.stop:
+			return
+		}
+	}
+	d.send(context.Background(), c, deviceID, p)
+}
+
+// send sends the value of p unless it was already taken by a concurrent flush
+func (d *debouncer) send(ctx context.Context, c *Client, deviceID string, p *debounced) error {
+	d.mu.Lock()
+	if d.pending[deviceID] != p {
+		d.mu.Unlock()
+		return nil
+	}
+	delete(d.pending, deviceID)
+	close(p.stop)
+	d.mu.Unlock()
+
+	f := p.f
+	err := c.Update(ctx, deviceID, UpdateRequest{SetTemperatureF: &f})
+	for _, waiter := range p.waiters {
+		waiter <- err
+	}
+	return err
+}
+
+// flush sends all pending values right away, returning the first error encountered
+func (d *debouncer) flush(ctx context.Context, c *Client) error {
+	d.mu.Lock()
+	pending := make(map[string]*debounced, len(d.pending))
+	for deviceID, p := range d.pending {
+		pending[deviceID] = p
+	}
+	d.mu.Unlock()
+
+	var firstErr error
+	for deviceID, p := range pending {
+		if err := d.send(ctx, c, deviceID, p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,102 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForDebounced blocks until n calls wait for the pending debounced value of deviceID
+func waitForDebounced(c *Client, deviceID string, n int) {
+	for {
+		c.debouncer.mu.Lock()
+		p := c.debouncer.pending[deviceID]
+		waiting := p != nil && len(p.waiters) >= n
+		c.debouncer.mu.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetTemperatureDebouncedSendsFinalValue(t *testing.T) {
+	var sent []float64
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, recordTemperatureF(&sent), WithClock(clock))
+	const quiet = time.Second
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i, f := range []float64{60, 62, 65, 68, 70} {
+		f := f
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.SetTemperatureDebounced(context.Background(), "device", f, quiet)
+		}()
+		waitForDebounced(c, "device", i+1)
+		clock.BlockUntil(1)
+		clock.Advance(quiet / 2)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(quiet)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+	assert.DeepEqual(t, sent, []float64{70})
+}
+
+func TestSetTemperatureDebouncedIgnoresCancelledCaller(t *testing.T) {
+	sent := make(chan float64, 1)
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			SetTemperatureF float64 `json:"set_temperature_f"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent <- body.SetTemperatureF
+	}), WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.SetTemperatureDebounced(ctx, "device", 70, time.Second)
+	}()
+	waitForDebounced(c, "device", 1)
+	cancel()
+	assert.Equal(t, <-done, context.Canceled)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	select {
+	case f := <-sent:
+		assert.Equal(t, f, float64(70))
+	case <-time.After(5 * time.Second):
+		t.Fatal("final value was not sent")
+	}
+}
+
+func TestFlushSendsDebouncedValue(t *testing.T) {
+	var sent []float64
+	c := newTestClient(t, recordTemperatureF(&sent))
+
+	done := make(chan error)
+	go func() {
+		done <- c.SetTemperatureDebounced(context.Background(), "device", 70, time.Hour)
+	}()
+	waitForDebounced(c, "device", 1)
+
+	assert.NilError(t, c.Close())
+	assert.NilError(t, <-done)
+	assert.DeepEqual(t, sent, []float64{70})
+
+	assert.ErrorContains(t, c.SetTemperatureDebounced(context.Background(), "device", 70, 0), "must be positive")
+}
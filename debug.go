@@ -0,0 +1,94 @@
+package sleepme
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// WithDebugDump writes every request and response, including bodies, to w, e.g. for attaching to bug reports.
+// The Authorization header is redacted. Dumping buffers bodies in memory; don't use it in production
+func WithDebugDump(w io.Writer) func(*Client) error {
+	return func(c *Client) error {
+		if w == nil {
+			return errors.New("debug dump writer must not be nil")
+		}
+		d := &debugDumper{w: w}
+		c.middleware = append(c.middleware, d.middleware)
+		return nil
+	}
+}
+
+// debugDumper serializes dumps of concurrent requests to its writer
+type debugDumper struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (d *debugDumper) write(dump []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.w.Write(dump)
+	io.WriteString(d.w, "\n")
+}
+
+// dumpRequest dumps req with a redacted Authorization header. It returns a clone of req to send instead,
+// as reading the body for the dump consumes it and a RoundTripper must not modify the request it was given
+func (d *debugDumper) dumpRequest(req *http.Request) (*http.Request, error) {
+	send := req.Clone(req.Context())
+	dumped := req.Clone(req.Context())
+	if dumped.Header.Get("Authorization") != "" {
+		dumped.Header.Set("Authorization", redacted)
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		bs, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		send.Body = io.NopCloser(bytes.NewReader(bs))
+		send.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(bs)), nil
+		}
+		dumped.Body = io.NopCloser(bytes.NewReader(bs))
+	}
+
+	dump, err := httputil.DumpRequestOut(dumped, true)
+	if err != nil {
+		return nil, err
+	}
+	d.write(dump)
+	return send, nil
+}
+
+// dumpResponse dumps resp, replacing its body with an in-memory copy which can still be decoded
+func (d *debugDumper) dumpResponse(resp *http.Response) error {
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+	d.write(dump)
+	return nil
+}
+
+// middleware dumps every request and response passing through it
+func (d *debugDumper) middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		send, err := d.dumpRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(send)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.dumpResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return resp, nil
+	})
+}
@@ -0,0 +1,71 @@
+package sleepme
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithDebugDump(t *testing.T) {
+	var (
+		received map[string]interface{}
+		dump     bytes.Buffer
+	)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			json.NewDecoder(r.Body).Decode(&received)
+			return
+		}
+		var details DeviceDetails
+		details.About.Model = "DP999NA"
+		json.NewEncoder(w).Encode(details)
+	}), WithDebugDump(&dump))
+
+	details, err := c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.Equal(t, details.About.Model, "DP999NA", "dumping must not consume the response body")
+
+	level := 50
+	assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{BrightnessLevel: &level}))
+	assert.DeepEqual(t, received, map[string]interface{}{"brightness_level": float64(50)})
+
+	out := dump.String()
+	assert.Assert(t, strings.Contains(out, "GET /devices/device HTTP/1.1"), out)
+	assert.Assert(t, strings.Contains(out, `"model":"DP999NA"`), out)
+	assert.Assert(t, strings.Contains(out, "PATCH /devices/device HTTP/1.1"), out)
+	assert.Assert(t, strings.Contains(out, `{"brightness_level":50}`), out)
+	assert.Assert(t, strings.Contains(out, "Authorization: "+redacted), out)
+	assert.Assert(t, !strings.Contains(out, "test-token"), out)
+}
+
+func TestDebugDumpDoesNotModifyRequest(t *testing.T) {
+	var (
+		dump bytes.Buffer
+		sent string
+	)
+	rt := (&debugDumper{w: &dump}).middleware(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		bs, err := io.ReadAll(req.Body)
+		assert.NilError(t, err)
+		sent = string(bs)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}")), Request: req}, nil
+	}))
+
+	body := io.NopCloser(strings.NewReader(`{"brightness_level":50}`))
+	req, err := http.NewRequest("PATCH", "https://api.developer.sleep.me/v1/devices/device", body)
+	assert.NilError(t, err)
+	req.Header.Set("Authorization", "Bearer test-token")
+	resp, err := rt.RoundTrip(req)
+	assert.NilError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, sent, `{"brightness_level":50}`)
+	assert.Assert(t, strings.Contains(dump.String(), `{"brightness_level":50}`), dump.String())
+	assert.Assert(t, req.Body == body, "the body of the request was replaced")
+	assert.Assert(t, req.GetBody == nil, "the request got a GetBody")
+	assert.Equal(t, req.Header.Get("Authorization"), "Bearer test-token")
+}
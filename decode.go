@@ -0,0 +1,80 @@
+package sleepme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrResponseDecode is matched by errors for responses which are not valid JSON or don't match the
+// expected structure, see DecodeError
+var ErrResponseDecode = errors.New("failed to decode response")
+
+// DecodeError is returned when a response body can't be decoded
+type DecodeError struct {
+	Err error
+	// Snippet is the beginning of the response body, with the token redacted
+	Snippet string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response: %v: %s", e.Err, e.Snippet)
+}
+
+// Is makes DecodeError match ErrResponseDecode
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrResponseDecode
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// snippetLength is how much of a response body errors include
+const snippetLength = 128
+
+// snippetWriter keeps the first snippetLength bytes written to it
+type snippetWriter struct {
+	buf []byte
+}
+
+func (w *snippetWriter) Write(p []byte) (int, error) {
+	if n := snippetLength - len(w.buf); n > 0 {
+		if n > len(p) {
+			n = len(p)
+		}
+		w.buf = append(w.buf, p[:n]...)
+	}
+	return len(p), nil
+}
+
+// structureError is returned by stream decoders for JSON which doesn't have the expected structure
+type structureError struct {
+	want string
+	got  interface{}
+}
+
+func (e *structureError) Error() string {
+	return fmt.Sprintf("expected %s, got %v", e.want, e.got)
+}
+
+// decodeError wraps errors of the JSON decoder, including an empty body, in a DecodeError with the
+// beginning of body. Other errors, e.g. of reading the body, are returned as they are
+func (c *Client) decodeError(err error, body []byte) error {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		structureErr *structureError
+	)
+	if err != io.EOF && !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr) && !errors.As(err, &structureErr) {
+		return err
+	}
+	if len(body) > snippetLength {
+		body = body[:snippetLength]
+	}
+	c.tokenMu.Lock()
+	token := c.token
+	c.tokenMu.Unlock()
+	return &DecodeError{Err: err, Snippet: redact(string(body), token)}
+}
@@ -0,0 +1,75 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"gotest.tools/v3/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		body    string
+		snippet string
+	}{
+		{name: "malformed", body: `{"about": {"model": "DP999NA",]}`, snippet: `{"about": {"model": "DP999NA",]}`},
+		{name: "wrong type", body: `{"control": {"brightness_level": "bright"}}`, snippet: `{"control": {"brightness_level": "bright"}}`},
+		{name: "token", body: `{"error": "invalid token test-token"]`, snippet: `{"error": "invalid token [REDACTED]"]`},
+		{name: "long", body: `{"about": ` + strings.Repeat(" ", 200) + `]`, snippet: `{"about": ` + strings.Repeat(" ", snippetLength-10)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tc.body)
+			}))
+
+			_, err := c.Get(context.Background(), "device")
+			assert.Assert(t, errors.Is(err, ErrResponseDecode), "expected a decode error, got %v", err)
+			var decodeErr *DecodeError
+			assert.Assert(t, errors.As(err, &decodeErr))
+			assert.Equal(t, decodeErr.Snippet, tc.snippet)
+			assert.Assert(t, !strings.Contains(err.Error(), "test-token"), err.Error())
+		})
+	}
+}
+
+func TestDecodeErrorInUpdateEcho(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"control": [}`)
+	}))
+
+	level := 10
+	_, err := c.UpdateWithResult(context.Background(), "device", UpdateRequest{BrightnessLevel: &level})
+	assert.Assert(t, errors.Is(err, ErrResponseDecode), "expected a decode error, got %v", err)
+}
+
+func TestDecodeErrorKeepsOtherErrors(t *testing.T) {
+	c := newTestClient(t, truncatingHandler(t, 1))
+
+	_, err := c.Get(context.Background(), "device")
+	assert.Assert(t, errors.Is(err, ErrTruncatedResponse), "expected a truncated response, got %v", err)
+	assert.Assert(t, !errors.Is(err, ErrResponseDecode))
+}
+
+func TestDecodeErrorInDeviceList(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{}`)
+	}))
+
+	err := c.ListDevicesFunc(context.Background(), func(Device) error { return nil })
+	assert.Assert(t, errors.Is(err, ErrResponseDecode), "expected a decode error, got %v", err)
+	var decodeErr *DecodeError
+	assert.Assert(t, errors.As(err, &decodeErr))
+	assert.Equal(t, decodeErr.Snippet, `{}`)
+}
+
+func TestDecodeErrorForEmptyBody(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	_, err := c.Get(context.Background(), "device")
+	assert.Assert(t, errors.Is(err, ErrResponseDecode), "expected a decode error, got %v", err)
+	assert.Assert(t, errors.Is(err, io.EOF))
+}
@@ -0,0 +1,49 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoDefaultDevice is returned by the default device shortcuts like GetDefault when the client
+// was created without WithDefaultDevice
+var ErrNoDefaultDevice = errors.New("no default device configured")
+
+// WithDefaultDevice sets the device used by shortcuts like GetDefault, for apps controlling a single Dock Pro.
+// All other methods keep taking a device ID
+func WithDefaultDevice(deviceID string) func(*Client) error {
+	return func(c *Client) error {
+		if deviceID == "" {
+			return fmt.Errorf("default device ID must not be empty")
+		}
+		c.defaultDevice = deviceID
+		return nil
+	}
+}
+
+// defaultDeviceID returns the device configured WithDefaultDevice
+func (c *Client) defaultDeviceID() (string, error) {
+	if c.defaultDevice == "" {
+		return "", ErrNoDefaultDevice
+	}
+	return c.defaultDevice, nil
+}
+
+// GetDefault is Get for the default device
+func (c *Client) GetDefault(ctx context.Context) (*DeviceDetails, error) {
+	deviceID, err := c.defaultDeviceID()
+	if err != nil {
+		return nil, err
+	}
+	return c.Get(ctx, deviceID)
+}
+
+// UpdateDefault is Update for the default device
+func (c *Client) UpdateDefault(ctx context.Context, r UpdateRequest) error {
+	deviceID, err := c.defaultDeviceID()
+	if err != nil {
+		return err
+	}
+	return c.Update(ctx, deviceID, r)
+}
@@ -0,0 +1,41 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func TestDefaultDevice(t *testing.T) {
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	})
+	c := newTestClient(t, handler, WithDefaultDevice("bedroom"))
+
+	_, err := c.GetDefault(context.Background())
+	assert.NilError(t, err)
+	status := ThermalControlStatusStandby
+	assert.NilError(t, c.UpdateDefault(context.Background(), UpdateRequest{ThermalControlStatus: &status}))
+	_, err = c.Get(context.Background(), "guest")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, paths, []string{"GET /devices/bedroom", "PATCH /devices/bedroom", "GET /devices/guest"})
+}
+
+func TestDefaultDeviceMissing(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+
+	_, err := c.GetDefault(context.Background())
+	assert.Assert(t, errors.Is(err, ErrNoDefaultDevice), "expected no default device, got %v", err)
+	err = c.UpdateDefault(context.Background(), UpdateRequest{})
+	assert.Assert(t, errors.Is(err, ErrNoDefaultDevice), "expected no default device, got %v", err)
+
+	_, err = New("token", WithDefaultDevice(""))
+	assert.ErrorContains(t, err, "must not be empty")
+}
@@ -0,0 +1,126 @@
+package sleepme
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func (s ThermalControlStatus) valid() bool {
+	return s == ThermalControlStatusActive || s == ThermalControlStatusStandby
+}
+
+// MarshalJSON rejects statuses other than ThermalControlStatusActive and ThermalControlStatusStandby
+func (s ThermalControlStatus) MarshalJSON() ([]byte, error) {
+	if !s.valid() {
+		return nil, fmt.Errorf("unknown thermal_control_status %q", string(s))
+	}
+	return json.Marshal(string(s))
+}
+
+// UnmarshalJSON keeps unknown statuses as they are, so newer API values don't break decoding
+func (s *ThermalControlStatus) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = ThermalControlStatus(v)
+	return nil
+}
+
+// IsActive reports whether the Dock Pro is heating or cooling
+func (s ThermalControlStatus) IsActive() bool {
+	return s == ThermalControlStatusActive
+}
+
+// IsStandby reports whether the Dock Pro is in standby
+func (s ThermalControlStatus) IsStandby() bool {
+	return s == ThermalControlStatusStandby
+}
+
+// String returns the status as reported by the API, including unknown ones
+func (s ThermalControlStatus) String() string {
+	return string(s)
+}
+
+// ThermalStatus returns Control.ThermalControlStatus as a ThermalControlStatus.
+// Unknown statuses are kept as they are, so neither IsActive nor IsStandby reports true for them.
+// It can't be called Status, as that is the name of the status field
+func (d *DeviceDetails) ThermalStatus() ThermalControlStatus {
+	return ThermalControlStatus(d.Control.ThermalControlStatus)
+}
+
+func (u DisplayTemperatureUnit) valid() bool {
+	return u == DisplayTemperatureUnitC || u == DisplayTemperatureUnitF
+}
+
+// MarshalJSON rejects units other than DisplayTemperatureUnitC and DisplayTemperatureUnitF
+func (u DisplayTemperatureUnit) MarshalJSON() ([]byte, error) {
+	if !u.valid() {
+		return nil, fmt.Errorf("unknown display_temperature_unit %q", string(u))
+	}
+	return json.Marshal(string(u))
+}
+
+// UnmarshalJSON keeps unknown units as they are, so newer API values don't break decoding.
+// Units are normalized to lower case, so "C" decodes as DisplayTemperatureUnitC
+func (u *DisplayTemperatureUnit) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*u = DisplayTemperatureUnit(strings.ToLower(v))
+	return nil
+}
+
+// rawUpdateRequest is the body sent for an UpdateRequest. Its status and unit are plain strings, so
+// clients created WithSkipValidation can send values the enums reject when marshaled
+type rawUpdateRequest struct {
+	ThermalControlStatus   *string  `json:"thermal_control_status,omitempty"`
+	SetTemperatureF        *float64 `json:"set_temperature_f,omitempty"`
+	SetTemperatureC        *float64 `json:"set_temperature_c,omitempty"`
+	DisplayTemperatureUnit *string  `json:"display_temperature_unit,omitempty"`
+	TimeZone               *string  `json:"time_zone,omitempty"`
+	BrightnessLevel        *int     `json:"brightness_level,omitempty"`
+}
+
+func (r UpdateRequest) raw() rawUpdateRequest {
+	raw := rawUpdateRequest{
+		SetTemperatureF: r.SetTemperatureF,
+		SetTemperatureC: r.SetTemperatureC,
+		TimeZone:        r.TimeZone,
+		BrightnessLevel: r.BrightnessLevel,
+	}
+	if r.ThermalControlStatus != nil {
+		status := string(*r.ThermalControlStatus)
+		raw.ThermalControlStatus = &status
+	}
+	if r.DisplayTemperatureUnit != nil {
+		unit := string(*r.DisplayTemperatureUnit)
+		raw.DisplayTemperatureUnit = &unit
+	}
+	return raw
+}
+
+// rawDeviceConfig is DeviceConfig with its status and unit as plain strings, see DeviceConfig.MarshalJSON
+type rawDeviceConfig struct {
+	ThermalControlStatus   string  `json:"thermal_control_status"`
+	SetTemperatureF        float64 `json:"set_temperature_f"`
+	SetTemperatureC        float64 `json:"set_temperature_c"`
+	DisplayTemperatureUnit string  `json:"display_temperature_unit"`
+	TimeZone               string  `json:"time_zone"`
+	BrightnessLevel        int     `json:"brightness_level"`
+}
+
+// MarshalJSON stores the status and unit as they are, so the config of a device reporting values unknown
+// to the enums can still be saved. ApplyConfig validates them instead
+func (cfg DeviceConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rawDeviceConfig{
+		ThermalControlStatus:   string(cfg.ThermalControlStatus),
+		SetTemperatureF:        cfg.SetTemperatureF,
+		SetTemperatureC:        cfg.SetTemperatureC,
+		DisplayTemperatureUnit: string(cfg.DisplayTemperatureUnit),
+		TimeZone:               cfg.TimeZone,
+		BrightnessLevel:        cfg.BrightnessLevel,
+	})
+}
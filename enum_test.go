@@ -0,0 +1,92 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestEnumRoundTrip(t *testing.T) {
+	type enums struct {
+		Status ThermalControlStatus   `json:"status"`
+		Unit   DisplayTemperatureUnit `json:"unit"`
+	}
+	for _, v := range []enums{
+		{Status: ThermalControlStatusActive, Unit: DisplayTemperatureUnitC},
+		{Status: ThermalControlStatusStandby, Unit: DisplayTemperatureUnitF},
+	} {
+		bs, err := json.Marshal(v)
+		assert.NilError(t, err)
+		var decoded enums
+		assert.NilError(t, json.Unmarshal(bs, &decoded))
+		assert.DeepEqual(t, decoded, v)
+	}
+}
+
+func TestEnumUnknownValues(t *testing.T) {
+	var decoded struct {
+		Status ThermalControlStatus   `json:"status"`
+		Unit   DisplayTemperatureUnit `json:"unit"`
+	}
+	assert.NilError(t, json.Unmarshal([]byte(`{"status":"boost","unit":"k"}`), &decoded))
+	assert.Equal(t, decoded.Status, ThermalControlStatus("boost"))
+	assert.Equal(t, decoded.Unit, DisplayTemperatureUnit("k"))
+
+	_, err := json.Marshal(decoded.Status)
+	assert.ErrorContains(t, err, `unknown thermal_control_status "boost"`)
+	_, err = json.Marshal(decoded.Unit)
+	assert.ErrorContains(t, err, `unknown display_temperature_unit "k"`)
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"status":1}`), &decoded), "cannot unmarshal")
+}
+
+func TestThermalStatus(t *testing.T) {
+	for _, tc := range []struct {
+		raw             string
+		active, standby bool
+	}{
+		{raw: "active", active: true},
+		{raw: "standby", standby: true},
+		{raw: "boost"},
+		{raw: ""},
+	} {
+		var details DeviceDetails
+		details.Control.ThermalControlStatus = tc.raw
+		status := details.ThermalStatus()
+		assert.Equal(t, status.IsActive(), tc.active, "status %q", tc.raw)
+		assert.Equal(t, status.IsStandby(), tc.standby, "status %q", tc.raw)
+		assert.Equal(t, status.String(), tc.raw)
+	}
+}
+
+func TestDisplayTemperatureUnitIsCaseInsensitive(t *testing.T) {
+	var units []DisplayTemperatureUnit
+	assert.NilError(t, json.Unmarshal([]byte(`["C","F","c","K"]`), &units))
+	assert.DeepEqual(t, units, []DisplayTemperatureUnit{DisplayTemperatureUnitC, DisplayTemperatureUnitF, DisplayTemperatureUnitC, "k"})
+
+	var warnings []Warning
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"control":{"display_temperature_unit":"C","set_temperature_c":20}}`)
+	}), WithWarningHandler(func(w Warning) {
+		warnings = append(warnings, w)
+	}))
+	details, err := c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.Equal(t, DisplayTemperatureUnit(details.Control.DisplayTemperatureUnit), DisplayTemperatureUnitC)
+	assert.Equal(t, details.Control.SetTemperatureF, float64(68))
+	assert.Equal(t, len(warnings), 0)
+}
+
+func TestSkipValidationSendsUnknownEnums(t *testing.T) {
+	var body map[string]interface{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+	}), WithSkipValidation())
+
+	status := ThermalControlStatus("boost")
+	assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{ThermalControlStatus: &status}))
+	assert.DeepEqual(t, body, map[string]interface{}{"thermal_control_status": "boost"})
+}
@@ -0,0 +1,153 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportSchemaVersion is the version of the format written by ExportAll
+const ExportSchemaVersion = 1
+
+// ExportHeader is the first element of the JSON array written by ExportAll
+type ExportHeader struct {
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+}
+
+// ExportedDevice is an element of the JSON array written by ExportAll, following the ExportHeader
+type ExportedDevice struct {
+	Device  Device         `json:"device"`
+	Details *DeviceDetails `json:"details"`
+}
+
+// ExportAll writes the details of every device of the account to w, e.g. for backups or support tickets.
+// The output is a JSON array starting with an ExportHeader, followed by an ExportedDevice per device.
+// Devices are written as they are fetched, so memory use does not grow with the number of devices.
+// If ctx is cancelled midway the output is left incomplete
+func (c *Client) ExportAll(ctx context.Context, w io.Writer) error {
+	devices, err := c.ListDevices(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(ExportHeader{SchemaVersion: ExportSchemaVersion, ExportedAt: c.clock.Now()}); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		details, err := c.Get(ctx, device.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+		if err := enc.Encode(ExportedDevice{Device: device, Details: details}); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
+
+// ImportAll restores the settings of every device in an export written by ExportAll, matching devices by ID.
+// The result maps the ID of every exported device to the error restoring it, nil on success.
+// Devices no longer part of the account are skipped with a DeviceNotFoundError and a logged warning.
+// An error is only returned if the export can't be read or the devices of the account can't be listed
+func (c *Client) ImportAll(ctx context.Context, r io.Reader) (map[string]error, error) {
+	devices, err := c.ListDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		known[device.ID] = true
+	}
+
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("expected an export array, got %v", tok)
+	}
+	var header ExportHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, err
+	}
+	if header.SchemaVersion != ExportSchemaVersion {
+		return nil, fmt.Errorf("unsupported export schema version %d", header.SchemaVersion)
+	}
+
+	results := map[string]error{}
+	for dec.More() {
+		var exported ExportedDevice
+		if err := dec.Decode(&exported); err != nil {
+			return results, err
+		}
+		deviceID := exported.Device.ID
+		switch {
+		case exported.Details == nil:
+			results[deviceID] = errors.New("export contains no details")
+		case !known[deviceID]:
+			c.logf("sleepme: skipping import of device %s, which is not part of the account", deviceID)
+			results[deviceID] = &DeviceNotFoundError{DeviceID: deviceID}
+		default:
+			results[deviceID] = c.ApplyConfig(ctx, deviceID, configFromDetails(exported.Details))
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// DetailsRecord is a line written by StreamDetails
+type DetailsRecord struct {
+	Time     time.Time      `json:"time"`
+	DeviceID string         `json:"device_id"`
+	Details  *DeviceDetails `json:"details"`
+}
+
+// StreamDetails fetches the details of every device in deviceIDs right away and then every interval,
+// writing each as a DetailsRecord on its own line of JSON, e.g. for log ingestion. If w has a Flush method,
+// like a *bufio.Writer, it is flushed after every round. StreamDetails returns nil once ctx is cancelled,
+// or the first error fetching details or writing to w
+func (c *Client) StreamDetails(ctx context.Context, deviceIDs []string, interval time.Duration, w io.Writer) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	enc := json.NewEncoder(w)
+	for {
+		for _, deviceID := range deviceIDs {
+			details, err := c.Get(ctx, deviceID)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(DetailsRecord{Time: c.clock.Now(), DeviceID: deviceID, Details: details}); err != nil {
+				return err
+			}
+		}
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if c.sleepCtx(ctx, interval) != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package sleepme
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// accountHandler serves a fixed set of devices, using the model of each device to tell them apart
+func accountHandler(devices ...Device) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/devices" {
+			json.NewEncoder(w).Encode(devices)
+			return
+		}
+		for _, device := range devices {
+			if r.URL.Path == "/devices/"+device.ID {
+				details := testDeviceDetails()
+				details.About.Model = "model-" + device.ID
+				json.NewEncoder(w).Encode(details)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+func TestExportAll(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC))
+	c := newTestClient(t, accountHandler(Device{ID: "a", Name: "Left"}, Device{ID: "b", Name: "Right"}), WithClock(clock))
+
+	var buf bytes.Buffer
+	assert.NilError(t, c.ExportAll(context.Background(), &buf))
+
+	var elements []json.RawMessage
+	assert.NilError(t, json.Unmarshal(buf.Bytes(), &elements), "export is not a JSON array: %s", buf.String())
+	assert.Equal(t, len(elements), 3)
+
+	var header ExportHeader
+	assert.NilError(t, json.Unmarshal(elements[0], &header))
+	assert.DeepEqual(t, header, ExportHeader{SchemaVersion: ExportSchemaVersion, ExportedAt: clock.Now()})
+
+	for i, id := range []string{"a", "b"} {
+		var device ExportedDevice
+		assert.NilError(t, json.Unmarshal(elements[i+1], &device))
+		assert.Equal(t, device.Device.ID, id)
+		assert.Equal(t, device.Details.About.Model, "model-"+id)
+	}
+}
+
+func TestExportAllStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := accountHandler(Device{ID: "a"}, Device{ID: "b"})
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		devices.ServeHTTP(w, r)
+		if r.URL.Path == "/devices/a" {
+			cancel()
+		}
+	}))
+
+	var buf bytes.Buffer
+	err := c.ExportAll(ctx, &buf)
+	assert.Assert(t, errors.Is(err, context.Canceled), "expected cancellation, got %v", err)
+	assert.Assert(t, !strings.Contains(buf.String(), "model-b"))
+}
+
+func TestImportAll(t *testing.T) {
+	export := `[{"schema_version":1,"exported_at":"2023-01-01T12:00:00Z"},
+{"device":{"id":"a"},"details":{"control":{"thermal_control_status":"active","set_temperature_f":70,"display_temperature_unit":"f","time_zone":"UTC","brightness_level":10}}},
+{"device":{"id":"gone"},"details":{"control":{"thermal_control_status":"active","set_temperature_f":70,"display_temperature_unit":"f","time_zone":"UTC"}}},
+{"device":{"id":"b"},"details":{"control":{"thermal_control_status":"off","brightness_level":200}}}
+]`
+	var updated []string
+	devices := accountHandler(Device{ID: "a"}, Device{ID: "b"})
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			updated = append(updated, r.URL.Path)
+			return
+		}
+		devices.ServeHTTP(w, r)
+	}))
+
+	results, err := c.ImportAll(context.Background(), strings.NewReader(export))
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 3)
+	assert.NilError(t, results["a"])
+	assert.Assert(t, errors.Is(results["gone"], ErrDeviceNotFound))
+	var verr *ValidationError
+	assert.Assert(t, errors.As(results["b"], &verr), "expected a validation error, got %v", results["b"])
+	assert.DeepEqual(t, updated, []string{"/devices/a"})
+}
+
+func TestImportAllRoundTrip(t *testing.T) {
+	var updated []string
+	devices := accountHandler(Device{ID: "a"}, Device{ID: "b"})
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			updated = append(updated, r.URL.Path)
+			return
+		}
+		devices.ServeHTTP(w, r)
+	}))
+
+	var buf bytes.Buffer
+	assert.NilError(t, c.ExportAll(context.Background(), &buf))
+	results, err := c.ImportAll(context.Background(), &buf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, results, map[string]error{"a": nil, "b": nil})
+	assert.DeepEqual(t, updated, []string{"/devices/a", "/devices/b"})
+}
+
+func TestImportAllRejectsUnknownSchema(t *testing.T) {
+	c := newTestClient(t, accountHandler())
+
+	_, err := c.ImportAll(context.Background(), strings.NewReader(`[{"schema_version":2}]`))
+	assert.ErrorContains(t, err, "unsupported export schema version 2")
+}
+
+func TestStreamDetails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock(time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC))
+	c := newTestClient(t, accountHandler(Device{ID: "a"}, Device{ID: "b"}), WithClock(clock))
+
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	done := make(chan error)
+	go func() {
+		done <- c.StreamDetails(ctx, []string{"a", "b"}, time.Minute, out)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	cancel()
+	assert.NilError(t, <-done)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 4, buf.String())
+	var records []DetailsRecord
+	for _, line := range lines {
+		var record DetailsRecord
+		assert.NilError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	for i, want := range []struct {
+		deviceID string
+		at       time.Time
+	}{
+		{"a", clock.Now().Add(-time.Minute)},
+		{"b", clock.Now().Add(-time.Minute)},
+		{"a", clock.Now()},
+		{"b", clock.Now()},
+	} {
+		assert.Equal(t, records[i].DeviceID, want.deviceID)
+		assert.Assert(t, records[i].Time.Equal(want.at), "record %d at %s", i, records[i].Time)
+		assert.Equal(t, records[i].Details.About.Model, "model-"+want.deviceID)
+	}
+}
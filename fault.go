@@ -0,0 +1,31 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+)
+
+// FaultCode identifies a fault reported by a Dock Pro. The codes are not documented, so they are passed on as reported
+type FaultCode string
+
+// Fault is an active fault of a Dock Pro
+type Fault struct {
+	Code        FaultCode
+	Description string
+}
+
+// Faults returns the active faults of the device, as reported in Status.FaultCodes.
+// Without documented codes, every fault is described by its code
+func (d *DeviceDetails) Faults() []Fault {
+	faults := make([]Fault, 0, len(d.Status.FaultCodes))
+	for _, code := range d.Status.FaultCodes {
+		faults = append(faults, Fault{Code: code, Description: fmt.Sprintf("device reported fault %q", string(code))})
+	}
+	return faults
+}
+
+// ClearFaults acknowledges the active faults of a Dock Pro.
+// The API offers no way to acknowledge faults, so ClearFaults returns ErrNotSupported
+func (c *Client) ClearFaults(ctx context.Context, deviceID string) error {
+	return ErrNotSupported
+}
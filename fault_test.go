@@ -0,0 +1,43 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func TestFaults(t *testing.T) {
+	for _, tc := range []struct {
+		status string
+		want   []Fault
+	}{
+		{status: `{}`, want: []Fault{}},
+		{status: `{"fault_codes":null}`, want: []Fault{}},
+		{status: `{"fault_codes":[]}`, want: []Fault{}},
+		{status: `{"fault_codes":["E12"]}`, want: []Fault{{Code: "E12", Description: `device reported fault "E12"`}}},
+		{status: `{"fault_codes":["pump","sensor"]}`, want: []Fault{
+			{Code: "pump", Description: `device reported fault "pump"`},
+			{Code: "sensor", Description: `device reported fault "sensor"`},
+		}},
+	} {
+		var details DeviceDetails
+		assert.NilError(t, json.Unmarshal([]byte(`{"status":`+tc.status+`}`), &details))
+		assert.DeepEqual(t, details.Faults(), tc.want)
+	}
+
+	var details DeviceDetails
+	err := json.Unmarshal([]byte(`{"status":{"fault_codes":"E12"}}`), &details)
+	assert.ErrorContains(t, err, "cannot unmarshal")
+}
+
+func TestClearFaultsIsNotSupported(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+
+	err := c.ClearFaults(context.Background(), "device")
+	assert.Assert(t, errors.Is(err, ErrNotSupported), "expected not supported, got %v", err)
+}
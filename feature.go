@@ -0,0 +1,32 @@
+package sleepme
+
+// Feature names an optional device feature, see SupportsFeature
+type Feature string
+
+const (
+	FeatureSchedules   Feature = "schedules"
+	FeatureAutoDim     Feature = "auto_dim"
+	FeatureHalfDegreeF Feature = "half_degree_f"
+	FeatureLock        Feature = "lock"
+)
+
+// Supports reports whether the capabilities include feature. Unknown features are not supported
+func (c Capabilities) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureSchedules:
+		return c.SupportsSchedules
+	case FeatureAutoDim:
+		return c.SupportsAutoDim
+	case FeatureHalfDegreeF:
+		return c.SupportsHalfDegreeF
+	case FeatureLock:
+		return c.SupportsLock
+	}
+	return false
+}
+
+// SupportsFeature reports whether the model and firmware of details support feature according to
+// their Capabilities. Features gated by firmware are added as rules with a MinFirmware to DefaultCapabilities
+func SupportsFeature(details *DeviceDetails, feature Feature) bool {
+	return details.Capabilities().Supports(feature)
+}
@@ -0,0 +1,36 @@
+package sleepme
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestSupportsFeature(t *testing.T) {
+	var details DeviceDetails
+	details.About.Model = "DP999"
+	details.About.FirmwareVersion = "3.0.0"
+	for _, feature := range []Feature{FeatureSchedules, FeatureAutoDim, FeatureHalfDegreeF, FeatureLock, "unknown"} {
+		assert.Assert(t, !SupportsFeature(&details, feature), "%s is not supported by default", feature)
+	}
+
+	table := DefaultCapabilities
+	t.Cleanup(func() { DefaultCapabilities = table })
+	DefaultCapabilities = CapabilityTable{Rules: []CapabilityRule{
+		{ModelPrefix: "DP", MinFirmware: "2.1.0", Capabilities: Capabilities{SupportsHalfDegreeF: true, SupportsSchedules: true}},
+	}}
+	for _, tc := range []struct {
+		firmware string
+		feature  Feature
+		want     bool
+	}{
+		{firmware: "2.1.0", feature: FeatureHalfDegreeF, want: true},
+		{firmware: "2.10.0", feature: FeatureSchedules, want: true},
+		{firmware: "2.1.0", feature: FeatureLock},
+		{firmware: "2.0.9", feature: FeatureHalfDegreeF},
+		{firmware: "2.1.0-rc.1", feature: FeatureHalfDegreeF},
+		{firmware: "unknown", feature: FeatureHalfDegreeF},
+	} {
+		details.About.FirmwareVersion = tc.firmware
+		assert.Equal(t, SupportsFeature(&details, tc.feature), tc.want, "%s on %q", tc.feature, tc.firmware)
+	}
+}
@@ -0,0 +1,148 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FirmwareVersion is a parsed firmware version like 1.10.2 or 2.0.0-beta.1, which compares numerically
+type FirmwareVersion struct {
+	Segments []int
+	// PreRelease is the part after a dash. A pre-release is older than the release it precedes
+	PreRelease string
+}
+
+// ParseFirmwareVersion parses dot separated numeric segments, optionally prefixed with a v and
+// followed by a dash and a pre-release suffix
+func ParseFirmwareVersion(s string) (FirmwareVersion, error) {
+	version := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	var v FirmwareVersion
+	if i := strings.Index(version, "-"); i >= 0 {
+		version, v.PreRelease = version[:i], version[i+1:]
+		if v.PreRelease == "" {
+			return FirmwareVersion{}, fmt.Errorf("invalid firmware version %q: empty pre-release", s)
+		}
+	}
+	for _, segment := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(segment)
+		if err != nil || n < 0 {
+			return FirmwareVersion{}, fmt.Errorf("invalid firmware version %q", s)
+		}
+		v.Segments = append(v.Segments, n)
+	}
+	return v, nil
+}
+
+// String formats the version as it was parsed, without a v prefix
+func (v FirmwareVersion) String() string {
+	segments := make([]string, len(v.Segments))
+	for i, n := range v.Segments {
+		segments[i] = strconv.Itoa(n)
+	}
+	s := strings.Join(segments, ".")
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare returns -1 if v is older than o, 1 if it is newer, and 0 if they are equal.
+// Missing segments count as 0, so 1.2 equals 1.2.0
+func (v FirmwareVersion) Compare(o FirmwareVersion) int {
+	for i := 0; i < len(v.Segments) || i < len(o.Segments); i++ {
+		a, b := segmentAt(v.Segments, i), segmentAt(o.Segments, i)
+		if a != b {
+			return compareInts(a, b)
+		}
+	}
+
+	switch {
+	case v.PreRelease == o.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case o.PreRelease == "":
+		return -1
+	}
+	return comparePreReleases(v.PreRelease, o.PreRelease)
+}
+
+// comparePreReleases compares dot separated identifiers, numerically where both are numbers
+func comparePreReleases(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			return compareInts(an, bn)
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		}
+		return strings.Compare(as[i], bs[i])
+	}
+	return compareInts(len(as), len(bs))
+}
+
+func segmentAt(segments []int, i int) int {
+	if i < len(segments) {
+		return segments[i]
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// FirmwareVersionParsed parses About.FirmwareVersion, see ParseFirmwareVersion
+func (d *DeviceDetails) FirmwareVersionParsed() (FirmwareVersion, error) {
+	return ParseFirmwareVersion(d.About.FirmwareVersion)
+}
+
+// ErrFirmwareUpdateNotConfirmed is returned by StartFirmwareUpdate unless the caller confirmed the update
+var ErrFirmwareUpdateNotConfirmed = errors.New("firmware update not confirmed")
+
+// FirmwareUpdateProgress describes a running firmware update
+type FirmwareUpdateProgress struct {
+	// Percent is the progress of the update between 0 and 100
+	Percent int
+	// Done is set once the device finished updating
+	Done bool
+}
+
+// StartFirmwareUpdate installs the latest firmware on a Dock Pro. Interrupting the update, e.g. by
+// cutting power, can leave the device unusable, so confirm must be true for the update to start.
+// Confirmed calls return ErrNotSupported, as the API can't start updates
+func (c *Client) StartFirmwareUpdate(ctx context.Context, deviceID string, confirm bool) error {
+	if !confirm {
+		return ErrFirmwareUpdateNotConfirmed
+	}
+	return ErrNotSupported
+}
+
+// WatchFirmwareUpdate polls the progress of a firmware update every interval, with the channel
+// semantics of WatchDevice. The API doesn't report update progress, so ErrNotSupported is sent right away
+func (c *Client) WatchFirmwareUpdate(ctx context.Context, deviceID string, interval time.Duration) (<-chan FirmwareUpdateProgress, <-chan error) {
+	out := make(chan FirmwareUpdateProgress)
+	errc := make(chan error, 1)
+	errc <- ErrNotSupported
+	close(out)
+	close(errc)
+	return out, errc
+}
@@ -0,0 +1,75 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseFirmwareVersion(t *testing.T) {
+	v, err := ParseFirmwareVersion("v2.10.3-beta.1")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, v, FirmwareVersion{Segments: []int{2, 10, 3}, PreRelease: "beta.1"})
+	assert.Equal(t, v.String(), "2.10.3-beta.1")
+
+	for _, invalid := range []string{"", "1..2", "1.x", "1.2-", "-1.2"} {
+		_, err := ParseFirmwareVersion(invalid)
+		assert.ErrorContains(t, err, "invalid firmware version", "expected %q to be invalid", invalid)
+	}
+}
+
+func TestFirmwareVersionCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"1.2", "1.2.0", 0},
+		{"1.2.1", "1.2", 1},
+		{"10.0.0", "9.99.99", 1},
+		{"2.0.0-beta", "2.0.0", -1},
+		{"2.0.0", "2.0.0-rc.1", 1},
+		{"2.0.0-beta.2", "2.0.0-beta.10", -1},
+		{"2.0.0-alpha", "2.0.0-beta", -1},
+		{"2.0.0-beta", "2.0.0-beta.1", -1},
+		{"2.0.0-1", "2.0.0-beta", -1},
+		{"2.0.0-beta", "2.0.0-beta", 0},
+	} {
+		a, err := ParseFirmwareVersion(tc.a)
+		assert.NilError(t, err)
+		b, err := ParseFirmwareVersion(tc.b)
+		assert.NilError(t, err)
+		assert.Equal(t, a.Compare(b), tc.want, "%s vs %s", tc.a, tc.b)
+	}
+}
+
+func TestDeviceDetailsFirmwareVersionParsed(t *testing.T) {
+	var details DeviceDetails
+	details.About.FirmwareVersion = "5.39.2153"
+
+	v, err := details.FirmwareVersionParsed()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, v.Segments, []int{5, 39, 2153})
+}
+
+func TestFirmwareUpdateIsNotSupported(t *testing.T) {
+	var calls int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	err := c.StartFirmwareUpdate(context.Background(), "device", false)
+	assert.Assert(t, errors.Is(err, ErrFirmwareUpdateNotConfirmed), "expected unconfirmed, got %v", err)
+	err = c.StartFirmwareUpdate(context.Background(), "device", true)
+	assert.Assert(t, errors.Is(err, ErrNotSupported), "expected not supported, got %v", err)
+
+	progress, errc := c.WatchFirmwareUpdate(context.Background(), "device", time.Second)
+	assert.Assert(t, errors.Is(<-errc, ErrNotSupported))
+	_, open := <-progress
+	assert.Assert(t, !open)
+	assert.Equal(t, calls, 0)
+}
@@ -2,6 +2,9 @@ module github.com/nicolai86/sleepme
 
 go 1.19
 
-require gotest.tools/v3 v3.4.0
+require (
+	golang.org/x/time v0.5.0
+	gotest.tools/v3 v3.4.0
+)
 
 require github.com/google/go-cmp v0.5.5 // indirect
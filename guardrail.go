@@ -0,0 +1,78 @@
+package sleepme
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrGuardrailViolation is matched by errors for set temperatures outside of the guardrails of the client,
+// see GuardrailError
+var ErrGuardrailViolation = errors.New("set temperature violates guardrails")
+
+// GuardrailError is returned for a set temperature outside of the bounds configured WithSetpointGuardrails.
+// Value, Min and Max are given in the unit of Field
+type GuardrailError struct {
+	Field    string
+	Value    float64
+	Min, Max float64
+}
+
+func (e *GuardrailError) Error() string {
+	return fmt.Sprintf("%s %v is outside of the guardrails [%v, %v]", e.Field, e.Value, e.Min, e.Max)
+}
+
+// Is makes GuardrailError match ErrGuardrailViolation
+func (e *GuardrailError) Is(target error) bool {
+	return target == ErrGuardrailViolation
+}
+
+// guardrails are the bounds of set temperatures, in Fahrenheit
+type guardrails struct {
+	minF, maxF float64
+}
+
+// WithSetpointGuardrails restricts set temperatures to [minF, maxF] degrees Fahrenheit, e.g. for a child's bed,
+// independent of the range supported by the device. Update and all temperature setters reject set temperatures
+// outside of the guardrails with a *GuardrailError, or clamp them for clients created WithTemperaturePolicy(PolicyClamp).
+// PolicyPassthrough and WithSkipValidation don't lift guardrails. Celsius set temperatures are checked against
+// the converted bounds, and clamped to the whole degrees within them; if there are none, they are rejected
+func WithSetpointGuardrails(minF, maxF float64) func(*Client) error {
+	return func(c *Client) error {
+		if math.IsNaN(minF) || math.IsNaN(maxF) || minF > maxF {
+			return fmt.Errorf("invalid guardrails [%v, %v]", minF, maxF)
+		}
+		c.guardrails = &guardrails{minF: minF, maxF: maxF}
+		return nil
+	}
+}
+
+// applyGuardrails checks the set temperatures of r against the guardrails of the client, clamping them under PolicyClamp
+func (c *Client) applyGuardrails(r UpdateRequest) (UpdateRequest, error) {
+	g := c.guardrails
+	if g == nil {
+		return r, nil
+	}
+	if r.SetTemperatureF != nil {
+		if f := *r.SetTemperatureF; f < g.minF || f > g.maxF {
+			if c.temperaturePolicy != PolicyClamp {
+				return r, &GuardrailError{Field: "set_temperature_f", Value: f, Min: g.minF, Max: g.maxF}
+			}
+			r.SetTemperatureF = c.clamp("set_temperature_f", f, g.minF, g.maxF)
+		}
+	}
+	if r.SetTemperatureC != nil {
+		minC, maxC := CelsiusFromFahrenheit(g.minF), CelsiusFromFahrenheit(g.maxF)
+		if celsius := *r.SetTemperatureC; celsius < minC || celsius > maxC {
+			if c.temperaturePolicy != PolicyClamp {
+				return r, &GuardrailError{Field: "set_temperature_c", Value: celsius, Min: minC, Max: maxC}
+			}
+			// Celsius set temperatures are whole degrees, which guardrails narrower than that may not contain
+			if math.Ceil(minC) > math.Floor(maxC) {
+				return r, &GuardrailError{Field: "set_temperature_c", Value: celsius, Min: minC, Max: maxC}
+			}
+			r.SetTemperatureC = c.clamp("set_temperature_c", celsius, math.Ceil(minC), math.Floor(maxC))
+		}
+	}
+	return r, nil
+}
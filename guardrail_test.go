@@ -0,0 +1,85 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"gotest.tools/v3/assert"
+	"sync"
+	"testing"
+)
+
+func TestSetpointGuardrailsReject(t *testing.T) {
+	for _, opts := range [][]func(*Client) error{
+		{WithSetpointGuardrails(65, 80)},
+		{WithSetpointGuardrails(65, 80), WithTemperaturePolicy(PolicyPassthrough), WithSkipValidation()},
+	} {
+		var (
+			mu      sync.Mutex
+			updates []map[string]interface{}
+		)
+		c := newTestClient(t, recordUpdates(&mu, &updates), opts...)
+
+		assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 65))
+		assert.NilError(t, c.SetTemperatureC(context.Background(), "device", 26))
+
+		err := c.SetTemperatureF(context.Background(), "device", 90)
+		assert.Assert(t, errors.Is(err, ErrGuardrailViolation), "expected a guardrail violation, got %v", err)
+		var gerr *GuardrailError
+		assert.Assert(t, errors.As(err, &gerr))
+		assert.DeepEqual(t, *gerr, GuardrailError{Field: "set_temperature_f", Value: 90, Min: 65, Max: 80})
+
+		err = c.SetTemperatureC(context.Background(), "device", 15)
+		assert.Assert(t, errors.Is(err, ErrGuardrailViolation), "expected a guardrail violation, got %v", err)
+		assert.ErrorContains(t, err, "set_temperature_c 15 is outside of the guardrails")
+
+		status := ThermalControlStatusActive
+		f := float64(60)
+		err = c.Update(context.Background(), "device", UpdateRequest{ThermalControlStatus: &status, SetTemperatureF: &f})
+		assert.Assert(t, errors.Is(err, ErrGuardrailViolation), "expected a guardrail violation, got %v", err)
+		assert.Equal(t, len(updates), 2)
+	}
+}
+
+func TestSetpointGuardrailsClamp(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		updates  []map[string]interface{}
+		warnings []Warning
+	)
+	c := newTestClient(t, recordUpdates(&mu, &updates), WithSetpointGuardrails(65, 80), WithTemperaturePolicy(PolicyClamp),
+		WithWarningHandler(func(w Warning) { warnings = append(warnings, w) }))
+
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 90))
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 50))
+	assert.NilError(t, c.SetTemperatureC(context.Background(), "device", 30))
+	assert.NilError(t, c.SetTemperatureC(context.Background(), "device", 10))
+	assert.NilError(t, c.SetClimate(context.Background(), "device", ThermalControlStatusActive, 70, DisplayTemperatureUnitF))
+	assert.DeepEqual(t, updates, []map[string]interface{}{
+		{"set_temperature_f": float64(80)},
+		{"set_temperature_f": float64(65)},
+		{"set_temperature_c": float64(26)},
+		{"set_temperature_c": float64(19)},
+		{"thermal_control_status": "active", "set_temperature_f": float64(70)},
+	})
+	assert.Equal(t, len(warnings), 4)
+	assert.Equal(t, warnings[0].Message, "set_temperature_f 90 was clamped to 80")
+}
+
+func TestSetpointGuardrailsNarrowerThanADegreeCelsius(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		updates []map[string]interface{}
+	)
+	// 70°F to 71°F is 21.1°C to 21.7°C, which contains no whole degree Celsius
+	c := newTestClient(t, recordUpdates(&mu, &updates), WithSetpointGuardrails(70, 71), WithTemperaturePolicy(PolicyClamp))
+
+	err := c.SetTemperatureC(context.Background(), "device", 25)
+	assert.Assert(t, errors.Is(err, ErrGuardrailViolation), "expected a guardrail violation, got %v", err)
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 75))
+	assert.DeepEqual(t, updates, []map[string]interface{}{{"set_temperature_f": float64(71)}})
+}
+
+func TestSetpointGuardrailsInvalid(t *testing.T) {
+	_, err := New("token", WithSetpointGuardrails(80, 65))
+	assert.ErrorContains(t, err, "invalid guardrails")
+}
@@ -0,0 +1,77 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+)
+
+// IsOperational reports whether a Dock Pro is ready to heat or cool, e.g. before an automation turns it on.
+// If not, the reason explains why, for UIs to show
+func (d *DeviceDetails) IsOperational() (bool, string) {
+	switch {
+	case !d.Status.IsConnected:
+		return false, "device is disconnected"
+	case d.Status.IsWaterLow:
+		return false, "water level is low"
+	}
+	return true, ""
+}
+
+// FleetHealth summarizes the state of all devices of an account
+type FleetHealth struct {
+	Total        int
+	Connected    int
+	Disconnected int
+	WaterLow     int
+	// Active counts devices which are currently heating or cooling
+	Active int
+	// Failed counts devices whose details couldn't be fetched
+	Failed int
+	// NeedsAttention lists devices which are not operational or failed to fetch
+	NeedsAttention []DeviceAttention
+}
+
+// DeviceAttention explains why a device needs attention
+type DeviceAttention struct {
+	Device Device
+	Reason string
+	// Err is set if the details of the device couldn't be fetched
+	Err error
+}
+
+// FleetHealth fetches every device of the account with GetAll and summarizes their state.
+// Devices which fail to fetch are counted as Failed and listed in NeedsAttention with their error
+func (c *Client) FleetHealth(ctx context.Context) (FleetHealth, error) {
+	results, err := c.GetAll(ctx)
+	if err != nil {
+		return FleetHealth{}, err
+	}
+	health := FleetHealth{Total: len(results)}
+	for _, result := range results {
+		if result.Err != nil {
+			health.Failed++
+			health.NeedsAttention = append(health.NeedsAttention, DeviceAttention{
+				Device: result.Device,
+				Reason: fmt.Sprintf("failed to fetch details: %s", result.Err),
+				Err:    result.Err,
+			})
+			continue
+		}
+		details := result.Details
+		if details.Status.IsConnected {
+			health.Connected++
+		} else {
+			health.Disconnected++
+		}
+		if details.Status.IsWaterLow {
+			health.WaterLow++
+		}
+		if details.ThermalStatus().IsActive() {
+			health.Active++
+		}
+		if ok, reason := details.IsOperational(); !ok {
+			health.NeedsAttention = append(health.NeedsAttention, DeviceAttention{Device: result.Device, Reason: reason})
+		}
+	}
+	return health, nil
+}
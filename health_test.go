@@ -0,0 +1,76 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIsOperational(t *testing.T) {
+	for _, tc := range []struct {
+		connected, waterLow bool
+		operational         bool
+		reason              string
+	}{
+		{connected: true, operational: true},
+		{connected: false, reason: "device is disconnected"},
+		{connected: true, waterLow: true, reason: "water level is low"},
+		{connected: false, waterLow: true, reason: "device is disconnected"},
+	} {
+		var details DeviceDetails
+		details.Status.IsConnected = tc.connected
+		details.Status.IsWaterLow = tc.waterLow
+		operational, reason := details.IsOperational()
+		assert.Equal(t, operational, tc.operational)
+		assert.Equal(t, reason, tc.reason)
+	}
+}
+
+func TestFleetHealth(t *testing.T) {
+	devices := []Device{{ID: "ok"}, {ID: "offline"}, {ID: "dry"}, {ID: "gone"}}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/devices" {
+			json.NewEncoder(w).Encode(devices)
+			return
+		}
+		var details DeviceDetails
+		details.Status.IsConnected = true
+		switch strings.TrimPrefix(r.URL.Path, "/devices/") {
+		case "ok":
+			details.Control.ThermalControlStatus = "active"
+		case "offline":
+			details.Status.IsConnected = false
+		case "dry":
+			details.Control.ThermalControlStatus = "active"
+			details.Status.IsWaterLow = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	health, err := c.FleetHealth(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, len(health.NeedsAttention), 3)
+	failed := health.NeedsAttention[2]
+	assert.Assert(t, errors.Is(failed.Err, ErrDeviceNotFound), "expected not found, got %v", failed.Err)
+	health.NeedsAttention[2].Err = nil
+	assert.DeepEqual(t, health, FleetHealth{
+		Total:        4,
+		Connected:    2,
+		Disconnected: 1,
+		WaterLow:     1,
+		Active:       2,
+		Failed:       1,
+		NeedsAttention: []DeviceAttention{
+			{Device: Device{ID: "offline"}, Reason: "device is disconnected"},
+			{Device: Device{ID: "dry"}, Reason: "water level is low"},
+			{Device: Device{ID: "gone"}, Reason: `failed to fetch details: device "gone" not found`},
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package sleepme
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TemperaturePoint is a timestamped temperature reading of a Dock Pro
+type TemperaturePoint struct {
+	Time              time.Time
+	WaterTemperatureC float64
+	WaterTemperatureF float64
+	SetTemperatureC   int
+	SetTemperatureF   float64
+}
+
+// TemperatureRecorder keeps the most recent temperature readings of each device in memory.
+//
+// The sleep.me API does not offer historical data, so history is limited to readings seen by the recorder,
+// either recorded manually or collected by the watchers of a client created WithTemperatureRecorder
+type TemperatureRecorder struct {
+	size int
+
+	mu      sync.Mutex
+	devices map[string]*temperatureRing
+}
+
+// temperatureRing is a fixed size ring buffer of readings, oldest first starting at next once full
+type temperatureRing struct {
+	points []TemperaturePoint
+	next   int
+}
+
+// NewTemperatureRecorder creates a recorder retaining up to size readings per device
+func NewTemperatureRecorder(size int) (*TemperatureRecorder, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("recorder size must be positive, got %d", size)
+	}
+	return &TemperatureRecorder{
+		size:    size,
+		devices: map[string]*temperatureRing{},
+	}, nil
+}
+
+// WithTemperatureRecorder records every reading taken by the watchers of the client, e.g. WatchDevice
+func WithTemperatureRecorder(r *TemperatureRecorder) func(*Client) error {
+	return func(c *Client) error {
+		c.recorder = r
+		return nil
+	}
+}
+
+// Record stores a reading of a device taken at the given time, evicting its oldest reading when full
+func (r *TemperatureRecorder) Record(deviceID string, at time.Time, details *DeviceDetails) {
+	point := TemperaturePoint{
+		Time:              at,
+		WaterTemperatureC: details.Status.WaterTemperatureC,
+		WaterTemperatureF: details.Status.WaterTemperatureF,
+		SetTemperatureC:   details.Control.SetTemperatureC,
+		SetTemperatureF:   details.Control.SetTemperatureF,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring, ok := r.devices[deviceID]
+	if !ok {
+		ring = &temperatureRing{points: make([]TemperaturePoint, 0, r.size)}
+		r.devices[deviceID] = ring
+	}
+	if len(ring.points) < r.size {
+		ring.points = append(ring.points, point)
+		return
+	}
+	ring.points[ring.next] = point
+	ring.next = (ring.next + 1) % r.size
+}
+
+// TemperatureHistory returns the recorded readings of a device taken within [from, to], oldest first
+func (r *TemperatureRecorder) TemperatureHistory(deviceID string, from, to time.Time) []TemperaturePoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring, ok := r.devices[deviceID]
+	if !ok {
+		return nil
+	}
+	var res []TemperaturePoint
+	for i := range ring.points {
+		point := ring.points[(ring.next+i)%len(ring.points)]
+		if point.Time.Before(from) || point.Time.After(to) {
+			continue
+		}
+		res = append(res, point)
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTemperatureRecorderEvictsOldest(t *testing.T) {
+	r, err := NewTemperatureRecorder(3)
+	assert.NilError(t, err)
+
+	start := time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		var details DeviceDetails
+		details.Status.WaterTemperatureC = float64(20 + i)
+		details.Control.SetTemperatureF = float64(70 + i)
+		r.Record("device", start.Add(time.Duration(i)*time.Minute), &details)
+	}
+
+	history := r.TemperatureHistory("device", start, start.Add(time.Hour))
+	assert.Equal(t, len(history), 3)
+	for i, point := range history {
+		assert.Equal(t, point.Time, start.Add(time.Duration(i+2)*time.Minute))
+		assert.Equal(t, point.WaterTemperatureC, float64(22+i))
+		assert.Equal(t, point.SetTemperatureF, float64(72+i))
+	}
+
+	assert.Equal(t, len(r.TemperatureHistory("device", start.Add(3*time.Minute), start.Add(3*time.Minute))), 1)
+	assert.Equal(t, len(r.TemperatureHistory("other", start, start.Add(time.Hour))), 0)
+}
+
+func TestNewTemperatureRecorderRejectsInvalidSize(t *testing.T) {
+	_, err := NewTemperatureRecorder(0)
+	assert.ErrorContains(t, err, "recorder size must be positive")
+}
+
+func TestWatchDeviceRecordsTemperatures(t *testing.T) {
+	r, err := NewTemperatureRecorder(10)
+	assert.NilError(t, err)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var details DeviceDetails
+		details.Status.WaterTemperatureC = 21.5
+		json.NewEncoder(w).Encode(details)
+	}), WithTemperatureRecorder(r))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out, _ := c.WatchDevice(ctx, "device", time.Millisecond)
+	<-out
+	cancel()
+	for range out {
+	}
+
+	history := r.TemperatureHistory("device", time.Now().Add(-time.Minute), time.Now())
+	assert.Assert(t, len(history) > 0, "expected recorded readings")
+	assert.Equal(t, history[0].WaterTemperatureC, 21.5)
+}
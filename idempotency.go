@@ -0,0 +1,34 @@
+package sleepme
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// WithIdempotencyKey sends a random Idempotency-Key header with every mutating request, e.g. Update.
+// Retries of a call reuse its key, so an API honoring the header applies a change only once even when
+// a response got lost. APIs ignoring the header behave as before
+func WithIdempotencyKey() func(*Client) error {
+	return func(c *Client) error {
+		c.idempotencyKeys = true
+		return nil
+	}
+}
+
+// setIdempotencyKey adds a new key to mutating requests, if enabled WithIdempotencyKey
+func (c *Client) setIdempotencyKey(req *http.Request) error {
+	if !c.idempotencyKeys {
+		return nil
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	req.Header.Set("Idempotency-Key", hex.EncodeToString(key))
+	return nil
+}
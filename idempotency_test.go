@@ -0,0 +1,44 @@
+package sleepme
+
+import (
+	"context"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyIsReusedAcrossRetries(t *testing.T) {
+	var keys []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Method+" "+r.Header.Get("Idempotency-Key"))
+		if r.Method == "PATCH" && len(keys)%2 == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}), WithIdempotencyKey(), WithRetry(2), WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+
+	status := ThermalControlStatusActive
+	for i := 0; i < 2; i++ {
+		assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{ThermalControlStatus: &status}))
+	}
+	_, err := c.DeviceExists(context.Background(), "device")
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(keys), 5)
+	assert.Equal(t, len(keys[0]), len("PATCH ")+32)
+	assert.Equal(t, keys[0], keys[1])
+	assert.Equal(t, keys[2], keys[3])
+	assert.Assert(t, keys[0] != keys[2], "expected a new key per call")
+	assert.Equal(t, keys[4], "GET ")
+}
+
+func TestIdempotencyKeyIsOptional(t *testing.T) {
+	var key string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Idempotency-Key")
+	}))
+
+	status := ThermalControlStatusActive
+	assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{ThermalControlStatus: &status}))
+	assert.Equal(t, key, "")
+}
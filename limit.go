@@ -0,0 +1,78 @@
+package sleepme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxResponseBytes bounds the size of response bodies unless configured WithMaxResponseBytes.
+// Real responses are a few kilobytes, even for accounts with many devices
+const DefaultMaxResponseBytes = 10 << 20
+
+// maxRequestBytes bounds the size of request bodies. Real updates are a few hundred bytes,
+// so anything larger is a bug, e.g. an unvalidated string, rather than a request worth sending
+const maxRequestBytes = 64 << 10
+
+// ErrResponseTooLarge is matched by errors for responses larger than allowed WithMaxResponseBytes
+var ErrResponseTooLarge = errors.New("response too large")
+
+// WithMaxResponseBytes bounds the size of response bodies, protecting against misbehaving servers or proxies.
+// Reading more than n bytes fails with ErrResponseTooLarge. Defaults to DefaultMaxResponseBytes
+func WithMaxResponseBytes(n int64) func(*Client) error {
+	return func(c *Client) error {
+		if n <= 0 {
+			return fmt.Errorf("maximum response size must be positive, got %d", n)
+		}
+		c.maxResponseBytes = n
+		return nil
+	}
+}
+
+// maxBytesReader fails with ErrResponseTooLarge once more than max bytes were read from r
+type maxBytesReader struct {
+	r         io.Reader
+	max       int64
+	remaining int64
+}
+
+func newMaxBytesReader(r io.Reader, max int64) *maxBytesReader {
+	return &maxBytesReader{r: r, max: max, remaining: max}
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining < 0 {
+		return 0, m.tooLarge()
+	}
+	// read one byte more than allowed to tell a body of exactly max bytes from a larger one
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	if int64(n) > m.remaining {
+		n = int(m.remaining)
+		m.remaining = -1
+		return n, m.tooLarge()
+	}
+	m.remaining -= int64(n)
+	return n, err
+}
+
+func (m *maxBytesReader) tooLarge() error {
+	return fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, m.max)
+}
+
+// encodeBody encodes v as a JSON request body. The body is a *bytes.Reader so requests get a GetBody,
+// allowing them to be sent again on retries and token refreshes
+func encodeBody(v interface{}) (*bytes.Reader, error) {
+	bs := bytes.Buffer{}
+	if err := json.NewEncoder(&bs).Encode(v); err != nil {
+		return nil, err
+	}
+	if bs.Len() > maxRequestBytes {
+		return nil, fmt.Errorf("request body of %d bytes exceeds the maximum of %d bytes", bs.Len(), maxRequestBytes)
+	}
+	return bytes.NewReader(bs.Bytes()), nil
+}
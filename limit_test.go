@@ -0,0 +1,34 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"gotest.tools/v3/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	body := `{"about":{"model":"` + strings.Repeat("x", 1000) + `"}}`
+	var calls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, body)
+	})
+
+	c := newTestClient(t, handler, WithMaxResponseBytes(100), WithRetry(3))
+	_, err := c.Get(context.Background(), "device")
+	assert.Assert(t, errors.Is(err, ErrResponseTooLarge), "expected too large, got %v", err)
+	assert.ErrorContains(t, err, "more than 100 bytes")
+	assert.Equal(t, calls, 1)
+
+	c = newTestClient(t, handler, WithMaxResponseBytes(int64(len(body))))
+	details, err := c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.Equal(t, len(details.About.Model), 1000)
+
+	_, err = New("token", WithMaxResponseBytes(0))
+	assert.ErrorContains(t, err, "must be positive")
+}
@@ -0,0 +1,24 @@
+package sleepme
+
+import "context"
+
+// DeviceWithDetails is a device of the account together with its details, see ListDevicesWithDetails.
+// Details is nil if they couldn't be fetched, in which case Err says why
+type DeviceWithDetails struct {
+	Device
+	*DeviceDetails
+	Err error
+}
+
+// ListDevicesWithDetails is GetAll, embedding each device and its details for direct field access
+func (c *Client) ListDevicesWithDetails(ctx context.Context) ([]DeviceWithDetails, error) {
+	results, err := c.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]DeviceWithDetails, len(results))
+	for i, result := range results {
+		devices[i] = DeviceWithDetails{Device: result.Device, DeviceDetails: result.Details, Err: result.Err}
+	}
+	return devices, nil
+}
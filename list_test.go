@@ -0,0 +1,68 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestListDevicesWithDetails(t *testing.T) {
+	var devices []Device
+	for i := 0; i < 10; i++ {
+		devices = append(devices, Device{ID: fmt.Sprintf("%d", i)})
+	}
+	devices = append(devices, Device{ID: "gone"})
+	account := accountHandler(devices[:10]...)
+	var inFlight, maxInFlight int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/devices" {
+			accountHandler(devices...).ServeHTTP(w, r)
+			return
+		}
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if n <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, n) {
+				break
+			}
+		}
+		if r.URL.Path == "/devices/3" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		account.ServeHTTP(w, r)
+	}))
+
+	results, err := c.ListDevicesWithDetails(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), len(devices))
+	for i, result := range results {
+		assert.Equal(t, result.ID, devices[i].ID)
+		switch result.ID {
+		case "3":
+			assert.Error(t, result.Err, "expected 200, got 500")
+			assert.Assert(t, result.DeviceDetails == nil)
+		case "gone":
+			assert.Assert(t, errors.Is(result.Err, ErrDeviceNotFound), "expected not found, got %v", result.Err)
+			assert.Assert(t, result.DeviceDetails == nil)
+		default:
+			assert.NilError(t, result.Err)
+			assert.Equal(t, result.About.Model, "model-"+result.ID)
+		}
+	}
+	assert.Assert(t, atomic.LoadInt32(&maxInFlight) <= maxConcurrentDetails, "%d details fetched at once", maxInFlight)
+}
+
+func TestListDevicesWithDetailsFailsListing(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	_, err := c.ListDevicesWithDetails(context.Background())
+	assert.Error(t, err, "expected 200, got 502")
+}
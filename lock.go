@@ -0,0 +1,53 @@
+package sleepme
+
+import (
+	"context"
+	"sync"
+)
+
+// deviceLocks serializes writes per device while writes to different devices proceed in parallel
+type deviceLocks struct {
+	mu    sync.Mutex
+	locks map[string]*deviceLock
+}
+
+type deviceLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+// lock blocks until the lock of deviceID is held or ctx is done.
+// Locks are dropped once nobody holds or waits for them, so the map doesn't grow with every device ever written
+func (l *deviceLocks) lock(ctx context.Context, deviceID string) (unlock func(), err error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = map[string]*deviceLock{}
+	}
+	dl, ok := l.locks[deviceID]
+	if !ok {
+		dl = &deviceLock{ch: make(chan struct{}, 1)}
+		l.locks[deviceID] = dl
+	}
+	dl.refs++
+	l.mu.Unlock()
+
+	release := func() {
+		l.mu.Lock()
+		dl.refs--
+		if dl.refs == 0 {
+			delete(l.locks, deviceID)
+		}
+		l.mu.Unlock()
+	}
+
+	select {
+	case dl.ch <- struct{}{}:
+		return func() {
+			<-dl.ch
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package sleepme
+
+import (
+	"context"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdatesAreSerializedPerDevice(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight = map[string]int{}
+		maxSeen  = map[string]int{}
+	)
+	otherArrived := make(chan struct{})
+	var once sync.Once
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deviceID := strings.TrimPrefix(r.URL.Path, "/devices/")
+		mu.Lock()
+		inFlight[deviceID]++
+		if inFlight[deviceID] > maxSeen[deviceID] {
+			maxSeen[deviceID] = inFlight[deviceID]
+		}
+		mu.Unlock()
+
+		// the first write to bedroom only finishes once a write to guest arrived, proving they run in parallel
+		if deviceID == "guest" {
+			once.Do(func() { close(otherArrived) })
+		} else {
+			select {
+			case <-otherArrived:
+			case <-time.After(5 * time.Second):
+				t.Error("writes to different devices were serialized")
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		inFlight[deviceID]--
+		mu.Unlock()
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, deviceID := range []string{"bedroom", "guest"} {
+			deviceID := deviceID
+			temperature := float64(60 + i)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Check(t, c.Update(context.Background(), deviceID, UpdateRequest{SetTemperatureF: &temperature}))
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.DeepEqual(t, maxSeen, map[string]int{"bedroom": 1, "guest": 1})
+	assert.Equal(t, len(c.writeLocks.locks), 0)
+}
+
+func TestDeviceLockHonorsContext(t *testing.T) {
+	var locks deviceLocks
+	unlock, err := locks.lock(context.Background(), "device")
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = locks.lock(ctx, "device")
+	assert.Equal(t, err, context.Canceled)
+
+	unlock()
+	assert.Equal(t, len(locks.locks), 0)
+}
@@ -0,0 +1,93 @@
+package sleepme
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrUnknownAccount is returned by AccountManager for accounts which were never added, or were removed
+var ErrUnknownAccount = errors.New("unknown account")
+
+// AccountManager holds a Client per account, for services acting on behalf of many sleep.me users.
+// All clients share one connection pool, unless options configuring the transport, e.g. WithMinTLSVersion,
+// give a client a private one. It is safe for concurrent use
+type AccountManager struct {
+	transport *http.Transport
+	opts      []func(*Client) error
+
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewAccountManager returns a manager creating the clients of all accounts with opts, followed by the
+// options given to AddAccount
+func NewAccountManager(opts ...func(*Client) error) *AccountManager {
+	return &AccountManager{
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
+		opts:      opts,
+		clients:   map[string]*Client{},
+	}
+}
+
+// AddAccount creates the client of the account accountID, authenticated with token.
+// Adding an account twice fails; remove it first to replace its client
+func (m *AccountManager) AddAccount(accountID, token string, opts ...func(*Client) error) error {
+	if accountID == "" {
+		return errors.New("account ID must not be empty")
+	}
+	share := func(c *Client) error {
+		c.Client.Transport = m.transport
+		c.sharedTransport = true
+		return nil
+	}
+	all := append(append([]func(*Client) error{share}, m.opts...), opts...)
+	c, err := New(token, all...)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.clients[accountID]; ok {
+		return fmt.Errorf("account %q already exists", accountID)
+	}
+	m.clients[accountID] = c
+	return nil
+}
+
+// Client returns the client of the account accountID, or ErrUnknownAccount
+func (m *AccountManager) Client(accountID string) (*Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.clients[accountID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAccount, accountID)
+	}
+	return c, nil
+}
+
+// RemoveAccount removes the account accountID and closes its client, see Client.Close.
+// It returns ErrUnknownAccount if the account doesn't exist
+func (m *AccountManager) RemoveAccount(accountID string) error {
+	m.mu.Lock()
+	c, ok := m.clients[accountID]
+	delete(m.clients, accountID)
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownAccount, accountID)
+	}
+	return c.Close()
+}
+
+// Accounts returns the IDs of all accounts, in no particular order
+func (m *AccountManager) Accounts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.clients))
+	for id := range m.clients {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,67 @@
+package sleepme
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+func TestAccountManager(t *testing.T) {
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Device{})
+	}))
+	t.Cleanup(srv.Close)
+	m := NewAccountManager(WithAPIEndpoint(srv.URL))
+
+	assert.NilError(t, m.AddAccount("alice", "token-a"))
+	assert.NilError(t, m.AddAccount("bob", "token-b"))
+	assert.ErrorContains(t, m.AddAccount("alice", "token-c"), `account "alice" already exists`)
+	assert.ErrorContains(t, m.AddAccount("", "token"), "must not be empty")
+	assert.ErrorContains(t, m.AddAccount("carol", "token", WithRetry(0)), "max attempts")
+
+	ids := m.Accounts()
+	sort.Strings(ids)
+	assert.DeepEqual(t, ids, []string{"alice", "bob"})
+
+	for _, id := range []string{"alice", "bob"} {
+		c, err := m.Client(id)
+		assert.NilError(t, err)
+		_, err = c.ListDevices(context.Background())
+		assert.NilError(t, err)
+	}
+	assert.DeepEqual(t, tokens, []string{"Bearer token-a", "Bearer token-b"})
+
+	assert.NilError(t, m.RemoveAccount("alice"))
+	_, err := m.Client("alice")
+	assert.Assert(t, errors.Is(err, ErrUnknownAccount), "expected an unknown account, got %v", err)
+	assert.Assert(t, errors.Is(m.RemoveAccount("alice"), ErrUnknownAccount))
+	assert.NilError(t, m.AddAccount("alice", "token-d"), "removed accounts can be added again")
+}
+
+func TestAccountManagerSharesTransport(t *testing.T) {
+	m := NewAccountManager()
+	assert.NilError(t, m.AddAccount("alice", "token-a"))
+	assert.NilError(t, m.AddAccount("bob", "token-b"))
+	assert.NilError(t, m.AddAccount("carol", "token-c", WithMinTLSVersion(tls.VersionTLS13)))
+
+	alice, err := m.Client("alice")
+	assert.NilError(t, err)
+	bob, err := m.Client("bob")
+	assert.NilError(t, err)
+	carol, err := m.Client("carol")
+	assert.NilError(t, err)
+
+	assert.Equal(t, alice.Client.Transport, bob.Client.Transport)
+	assert.Assert(t, carol.Client.Transport != alice.Client.Transport, "configuring the transport of one account must not affect others")
+	assert.Equal(t, alice.Client.Transport.(*http.Transport).TLSClientConfig.MinVersion, uint16(0))
+	assert.Equal(t, carol.Client.Transport.(*http.Transport).TLSClientConfig.MinVersion, uint16(tls.VersionTLS13))
+}
@@ -0,0 +1,89 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Collector receives metrics about the requests sent by a client, e.g. to export them to Prometheus
+type Collector interface {
+	// ObserveRequest is called for every request sent, including retries.
+	// statusCode is 0 if the request failed without a response
+	ObserveRequest(method string, statusCode int, duration time.Duration)
+	// ObserveRateLimit is called for every response reporting the remaining request budget,
+	// and when the budget resets
+	ObserveRateLimit(remaining int, reset time.Time)
+}
+
+// TenantCollector is implemented by collectors which want to break down requests by tenant, see WithTenant.
+// Requests sent with a tenant are reported through ObserveTenantRequest instead of ObserveRequest
+type TenantCollector interface {
+	Collector
+	ObserveTenantRequest(tenant, method string, statusCode int, duration time.Duration)
+}
+
+type tenantKey struct{}
+
+// WithTenant labels the requests sent with the returned context with tenant, e.g. the account a
+// multi-account service acts for. The label is passed to collectors implementing TenantCollector
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set WithTenant, if any
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// WithMetrics reports metrics of all requests to collector
+func WithMetrics(collector Collector) func(*Client) error {
+	return func(c *Client) error {
+		if collector == nil {
+			return errors.New("collector must not be nil")
+		}
+		c.middleware = append(c.middleware, func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				start := c.clock.Now()
+				resp, err := next.RoundTrip(req)
+				observe(collector, req, resp, c.clock.Now().Sub(start))
+				return resp, err
+			})
+		})
+		return nil
+	}
+}
+
+// observe reports a single request to collector
+func observe(collector Collector, req *http.Request, resp *http.Response, duration time.Duration) {
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+		if remaining, reset, ok := parseRateLimit(resp.Header); ok {
+			collector.ObserveRateLimit(remaining, reset)
+		}
+	}
+	if tc, ok := collector.(TenantCollector); ok {
+		if tenant, ok := TenantFromContext(req.Context()); ok {
+			tc.ObserveTenantRequest(tenant, req.Method, statusCode, duration)
+			return
+		}
+	}
+	collector.ObserveRequest(req.Method, statusCode, duration)
+}
+
+// parseRateLimit reads the rate limit headers of a response. reset is given in unix seconds
+func parseRateLimit(h http.Header) (remaining int, reset time.Time, ok bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return remaining, time.Unix(seconds, 0), true
+}
@@ -0,0 +1,97 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type rateLimitObservation struct {
+	remaining int
+	reset     time.Time
+}
+
+type recordingCollector struct {
+	mu         sync.Mutex
+	statuses   []int
+	rateLimits []rateLimitObservation
+}
+
+func (r *recordingCollector) ObserveRequest(method string, statusCode int, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, statusCode)
+}
+
+func (r *recordingCollector) ObserveRateLimit(remaining int, reset time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rateLimits = append(r.rateLimits, rateLimitObservation{remaining: remaining, reset: reset})
+}
+
+func TestMetricsObserveRateLimit(t *testing.T) {
+	var requests int
+	collector := &recordingCollector{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "9")
+			w.Header().Set("X-RateLimit-Reset", "1700000000")
+		}
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithMetrics(collector))
+
+	for i := 0; i < 2; i++ {
+		_, err := c.ListDevices(context.Background())
+		assert.NilError(t, err)
+	}
+
+	assert.DeepEqual(t, collector.statuses, []int{200, 200})
+	assert.Equal(t, len(collector.rateLimits), 1)
+	assert.Equal(t, collector.rateLimits[0].remaining, 9)
+	assert.Assert(t, collector.rateLimits[0].reset.Equal(time.Unix(1700000000, 0)))
+}
+
+func TestParseRateLimitRequiresBothHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-RateLimit-Remaining", "5")
+	_, _, ok := parseRateLimit(h)
+	assert.Assert(t, !ok)
+}
+
+type tenantCollector struct {
+	recordingCollector
+	tenants []string
+}
+
+func (r *tenantCollector) ObserveTenantRequest(tenant, method string, statusCode int, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tenants = append(r.tenants, tenant)
+}
+
+func TestMetricsTenant(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Device{})
+	})
+	collector := &tenantCollector{}
+	c := newTestClient(t, handler, WithMetrics(collector))
+
+	_, err := c.ListDevices(WithTenant(context.Background(), "account-1"))
+	assert.NilError(t, err)
+	_, err = c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, collector.tenants, []string{"account-1"})
+	assert.DeepEqual(t, collector.statuses, []int{http.StatusOK})
+
+	// collectors without tenant support keep receiving every request
+	plain := &recordingCollector{}
+	c = newTestClient(t, handler, WithMetrics(plain))
+	_, err = c.ListDevices(WithTenant(context.Background(), "account-1"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, plain.statuses, []int{http.StatusOK})
+}
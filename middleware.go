@@ -0,0 +1,65 @@
+package sleepme
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Middleware wraps the transport sending requests to the API, e.g. to log, trace or modify them
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface, e.g. for writing Middleware
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds middleware around the transport of the client. Middleware run in the order they
+// were added, the first one outermost: it sees requests first and responses last. WithRateLimiter,
+// WithDebugDump and WithMetrics add their middleware at their position among the options.
+// Every attempt of a request retried WithRetry passes through all middleware
+func WithMiddleware(middleware ...Middleware) func(*Client) error {
+	return func(c *Client) error {
+		for _, m := range middleware {
+			if m == nil {
+				return errors.New("middleware must not be nil")
+			}
+		}
+		c.middleware = append(c.middleware, middleware...)
+		return nil
+	}
+}
+
+// httpClient returns c.Client with its transport wrapped by all middleware. The size limit set
+// WithMaxResponseBytes is the innermost layer, so no middleware can read an unbounded body
+func (c *Client) httpClient() *http.Client {
+	rt := c.Client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	rt = c.limitResponseBody(rt)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	client := *c.Client
+	client.Transport = rt
+	return &client
+}
+
+// limitResponseBody fails reading response bodies larger than allowed WithMaxResponseBytes with ErrResponseTooLarge
+func (c *Client) limitResponseBody(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{newMaxBytesReader(resp.Body, c.maxResponseBytes), resp.Body}
+		return resp, nil
+	})
+}
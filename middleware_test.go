@@ -0,0 +1,90 @@
+package sleepme
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tracing returns middleware appending name to trace before and after every request
+func tracing(trace *[]string, name string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*trace = append(*trace, name+" request")
+			resp, err := next.RoundTrip(req)
+			*trace = append(*trace, name+" response")
+			return resp, err
+		})
+	}
+}
+
+func TestWithMiddlewareOrder(t *testing.T) {
+	var trace []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace = append(trace, "server")
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithMiddleware(tracing(&trace, "first"), tracing(&trace, "second")), WithMiddleware(tracing(&trace, "third")))
+
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, trace, []string{
+		"first request", "second request", "third request",
+		"server",
+		"third response", "second response", "first response",
+	})
+
+	_, err = New("token", WithMiddleware(nil))
+	assert.ErrorContains(t, err, "must not be nil")
+}
+
+func TestWithMiddlewareModifiesRequests(t *testing.T) {
+	var seen string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("X-Request-Id")
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Request-Id", "abc")
+			return next.RoundTrip(req)
+		})
+	}))
+
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, seen, "abc")
+}
+
+func TestWithMiddlewareComposesWithBuiltins(t *testing.T) {
+	var (
+		trace     []string
+		dump      bytes.Buffer
+		collector recordingCollector
+		attempts  int
+	)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode([]Device{})
+	}),
+		WithMiddleware(tracing(&trace, "outer")),
+		WithMetrics(&collector),
+		WithDebugDump(&dump),
+		WithRetry(2), WithBackoff(ConstantBackoff{Delay: time.Millisecond}),
+	)
+
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	// every attempt passes through the whole chain
+	assert.DeepEqual(t, trace, []string{"outer request", "outer response", "outer request", "outer response"})
+	assert.DeepEqual(t, collector.statuses, []int{http.StatusServiceUnavailable, http.StatusOK})
+	assert.Equal(t, strings.Count(dump.String(), "GET /devices HTTP/1.1"), 2)
+}
@@ -0,0 +1,36 @@
+package sleepme
+
+import "math"
+
+// Mode tells whether a Dock Pro is heating or cooling, see DeviceDetails.ActiveMode
+type Mode string
+
+const (
+	// ModeOff is reported for devices which are not active
+	ModeOff  Mode = "off"
+	ModeHeat Mode = "heat"
+	ModeCool Mode = "cool"
+	// ModeHold is reported for active devices whose water is at the set temperature
+	ModeHold Mode = "hold"
+)
+
+// modeToleranceF is how far the water may be from the set temperature, in Fahrenheit, while being held at it
+const modeToleranceF = 1
+
+// ActiveMode derives whether a Dock Pro is heating or cooling from its set and water temperature.
+// The API has no mode of its own: an active device heats or cools towards its set temperature as needed,
+// so there is no way to set one either
+func (d *DeviceDetails) ActiveMode() Mode {
+	if !d.ThermalStatus().IsActive() {
+		return ModeOff
+	}
+	delta := d.Control.SetTemperatureF - d.Status.WaterTemperatureF
+	switch {
+	case math.Abs(delta) <= modeToleranceF:
+		return ModeHold
+	case delta > 0:
+		return ModeHeat
+	default:
+		return ModeCool
+	}
+}
@@ -0,0 +1,29 @@
+package sleepme
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestActiveMode(t *testing.T) {
+	for _, tc := range []struct {
+		status     ThermalControlStatus
+		set, water float64
+		want       Mode
+	}{
+		{status: ThermalControlStatusStandby, set: 80, water: 60, want: ModeOff},
+		{status: "unknown", set: 80, water: 60, want: ModeOff},
+		{status: ThermalControlStatusActive, set: 80, water: 60, want: ModeHeat},
+		{status: ThermalControlStatusActive, set: 80, water: 78.9, want: ModeHeat},
+		{status: ThermalControlStatusActive, set: 80, water: 79, want: ModeHold},
+		{status: ThermalControlStatusActive, set: 80, water: 80.5, want: ModeHold},
+		{status: ThermalControlStatusActive, set: 80, water: 81.5, want: ModeCool},
+		{status: ThermalControlStatusActive, set: 55, water: 70, want: ModeCool},
+	} {
+		var details DeviceDetails
+		details.Control.ThermalControlStatus = string(tc.status)
+		details.Control.SetTemperatureF = tc.set
+		details.Status.WaterTemperatureF = tc.water
+		assert.Equal(t, details.ActiveMode(), tc.want, "%s, set %v, water %v", tc.status, tc.set, tc.water)
+	}
+}
@@ -0,0 +1,127 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PairedDevices lists all Dock Pro units and groups those which appear to serve the same bed,
+// e.g. the left and right side of a two-sided setup.
+//
+// The API has no explicit grouping, so devices are grouped when they share at least one attachment;
+// sharing is transitive. Devices without a shared attachment form a group of their own.
+// Groups, and devices within a group, keep the order returned by ListDevices
+func (c *Client) PairedDevices(ctx context.Context) ([][]Device, error) {
+	devices, err := c.ListDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return groupDevices(devices), nil
+}
+
+func groupDevices(devices []Device) [][]Device {
+	// union-find over device indices, joined through shared attachments
+	parent := make([]int, len(devices))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := map[string]int{}
+	for i, device := range devices {
+		for _, attachment := range device.Attachments {
+			if j, ok := owner[attachment]; ok {
+				a, b := find(i), find(j)
+				if a > b {
+					a, b = b, a
+				}
+				parent[b] = a
+				continue
+			}
+			owner[attachment] = i
+		}
+	}
+
+	var groups [][]Device
+	groupOf := map[int]int{}
+	for i, device := range devices {
+		root := find(i)
+		g, ok := groupOf[root]
+		if !ok {
+			g = len(groups)
+			groupOf[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], device)
+	}
+	return groups
+}
+
+// MirrorTemperature keeps the set temperature of secondaryID in sync with primaryID until ctx is cancelled,
+// polling the primary every interval. The secondary is only updated when the two differ, so changes made
+// to the secondary are overwritten on the next change of the primary, but never ping-pong between the two
+func (c *Client) MirrorTemperature(ctx context.Context, primaryID, secondaryID string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	secondary, err := c.Get(ctx, secondaryID)
+	if err != nil {
+		return err
+	}
+	mirrored := secondary.Control.SetTemperatureF
+
+	return c.poll(ctx, primaryID, interval, func(primary *DeviceDetails) error {
+		if primary.Control.SetTemperatureF == mirrored {
+			return nil
+		}
+		temperature := primary.Control.SetTemperatureF
+		if err := c.Update(ctx, secondaryID, UpdateRequest{SetTemperatureF: &temperature}); err != nil {
+			return err
+		}
+		mirrored = primary.Control.SetTemperatureF
+		return nil
+	})
+}
+
+// ErrAmbiguousName is returned by DeviceByName when several devices carry the name
+var ErrAmbiguousName = errors.New("ambiguous device name")
+
+// DeviceByName finds the device with the given name, ignoring case. An exact match is preferred
+// over matches differing in case only. It returns ErrDeviceNotFound if no device carries the name,
+// and ErrAmbiguousName if several do
+func (c *Client) DeviceByName(ctx context.Context, name string) (*Device, error) {
+	devices, err := c.ListDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var exact, folded []Device
+	for _, device := range devices {
+		if device.Name == name {
+			exact = append(exact, device)
+		}
+		if strings.EqualFold(device.Name, name) {
+			folded = append(folded, device)
+		}
+	}
+	matches := folded
+	if len(exact) > 0 {
+		matches = exact
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: no device named %q", ErrDeviceNotFound, name)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("%w: %d devices named %q", ErrAmbiguousName, len(matches), name)
+}
@@ -0,0 +1,116 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPairedDevices(t *testing.T) {
+	devices := []Device{
+		{ID: "left", Attachments: []string{"bed-1"}},
+		{ID: "single"},
+		{ID: "right", Attachments: []string{"bed-1"}},
+		{ID: "guest", Attachments: []string{"bed-2"}},
+	}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(devices)
+	}))
+
+	groups, err := c.PairedDevices(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, groups, [][]Device{
+		{devices[0], devices[2]},
+		{devices[1]},
+		{devices[3]},
+	})
+}
+
+func TestGroupDevicesTransitive(t *testing.T) {
+	devices := []Device{
+		{ID: "a", Attachments: []string{"x"}},
+		{ID: "b", Attachments: []string{"y"}},
+		{ID: "c", Attachments: []string{"y", "x"}},
+	}
+	assert.DeepEqual(t, groupDevices(devices), [][]Device{devices})
+}
+
+func TestMirrorTemperature(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		primary   = []float64{70, 70, 72, 72, 65}
+		polls     int
+		secondary = float64(70)
+		writes    []float64
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var details DeviceDetails
+		switch {
+		case r.URL.Path == "/devices/secondary" && r.Method == "PATCH":
+			var body struct {
+				SetTemperatureF float64 `json:"set_temperature_f"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			secondary = body.SetTemperatureF
+			writes = append(writes, body.SetTemperatureF)
+			return
+		case r.URL.Path == "/devices/secondary":
+			details.Control.SetTemperatureF = secondary
+		default:
+			if polls == len(primary) {
+				cancel()
+				return
+			}
+			details.Control.SetTemperatureF = primary[polls]
+			polls++
+		}
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	assert.NilError(t, c.MirrorTemperature(ctx, "primary", "secondary", time.Millisecond))
+	assert.DeepEqual(t, writes, []float64{72, 65})
+}
+
+func TestDeviceByName(t *testing.T) {
+	devices := []Device{
+		{ID: "1", Name: "Master Bedroom"},
+		{ID: "2", Name: "Guest"},
+		{ID: "3", Name: "guest"},
+		{ID: "4", Name: "Kids"},
+		{ID: "5", Name: "KIDS"},
+	}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(devices)
+	}))
+
+	for _, tc := range []struct {
+		name string
+		id   string
+		err  error
+	}{
+		{name: "Master Bedroom", id: "1"},
+		{name: "master bedroom", id: "1"},
+		{name: "Guest", id: "2"},
+		{name: "guest", id: "3"},
+		{name: "kids", err: ErrAmbiguousName},
+		{name: "Attic", err: ErrDeviceNotFound},
+	} {
+		device, err := c.DeviceByName(context.Background(), tc.name)
+		if tc.err != nil {
+			assert.Assert(t, errors.Is(err, tc.err), "%s: expected %v, got %v", tc.name, tc.err, err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, device.ID, tc.id, tc.name)
+	}
+}
@@ -0,0 +1,194 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrPresetNotFound is returned for presets which were never saved
+var ErrPresetNotFound = errors.New("preset not found")
+
+// PresetBackend persists named presets for a PresetStore
+type PresetBackend interface {
+	// Load returns the preset saved under name, or ErrPresetNotFound
+	Load(name string) (DeviceConfig, error)
+	// Store saves a preset under name, replacing any previous one
+	Store(name string, cfg DeviceConfig) error
+	// Names returns the names of all saved presets
+	Names() ([]string, error)
+}
+
+// PresetStore manages named device configurations, e.g. "summer" and "winter"
+type PresetStore struct {
+	client  *Client
+	backend PresetBackend
+}
+
+// NewPresetStore creates a preset store applying presets through c.
+// Presets are kept in memory unless a backend is given, e.g. a FilePresetBackend
+func NewPresetStore(c *Client, backend PresetBackend) *PresetStore {
+	if backend == nil {
+		backend = NewMemoryPresetBackend()
+	}
+	return &PresetStore{client: c, backend: backend}
+}
+
+// Save validates cfg and saves it under name
+func (s *PresetStore) Save(name string, cfg DeviceConfig) error {
+	if name == "" {
+		return errors.New("preset name must not be empty")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return s.backend.Store(name, cfg)
+}
+
+// List returns the names of all saved presets in alphabetical order
+func (s *PresetStore) List() ([]string, error) {
+	names, err := s.backend.Names()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Apply reconfigures a Dock Pro to match the preset saved under name
+func (s *PresetStore) Apply(ctx context.Context, deviceID, name string) error {
+	cfg, err := s.backend.Load(name)
+	if err != nil {
+		return err
+	}
+	return s.client.ApplyConfig(ctx, deviceID, cfg)
+}
+
+// MemoryPresetBackend keeps presets in memory
+type MemoryPresetBackend struct {
+	mu      sync.Mutex
+	presets map[string]DeviceConfig
+}
+
+// NewMemoryPresetBackend creates an empty in-memory backend
+func NewMemoryPresetBackend() *MemoryPresetBackend {
+	return &MemoryPresetBackend{presets: map[string]DeviceConfig{}}
+}
+
+// Load returns the preset saved under name
+func (b *MemoryPresetBackend) Load(name string) (DeviceConfig, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cfg, ok := b.presets[name]
+	if !ok {
+		return DeviceConfig{}, fmt.Errorf("%w: %q", ErrPresetNotFound, name)
+	}
+	return cfg, nil
+}
+
+// Store saves a preset under name
+func (b *MemoryPresetBackend) Store(name string, cfg DeviceConfig) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.presets[name] = cfg
+	return nil
+}
+
+// Names returns the names of all saved presets
+func (b *MemoryPresetBackend) Names() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	names := make([]string, 0, len(b.presets))
+	for name := range b.presets {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// FilePresetBackend keeps presets in a JSON file, mapping names to configurations
+type FilePresetBackend struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Load returns the preset saved under name
+func (b *FilePresetBackend) Load(name string) (DeviceConfig, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	presets, err := b.read()
+	if err != nil {
+		return DeviceConfig{}, err
+	}
+	cfg, ok := presets[name]
+	if !ok {
+		return DeviceConfig{}, fmt.Errorf("%w: %q", ErrPresetNotFound, name)
+	}
+	return cfg, nil
+}
+
+// Store saves a preset under name, rewriting the file
+func (b *FilePresetBackend) Store(name string, cfg DeviceConfig) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	presets, err := b.read()
+	if err != nil {
+		return err
+	}
+	presets[name] = cfg
+	return b.write(presets)
+}
+
+// Names returns the names of all saved presets
+func (b *FilePresetBackend) Names() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	presets, err := b.read()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// read loads all presets. A missing file holds no presets
+func (b *FilePresetBackend) read() (map[string]DeviceConfig, error) {
+	presets := map[string]DeviceConfig{}
+	bs, err := os.ReadFile(b.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return presets, json.Unmarshal(bs, &presets)
+}
+
+// write replaces the file atomically, so a crash can't leave it half written
+func (b *FilePresetBackend) write(presets map[string]DeviceConfig) error {
+	bs, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.Path), filepath.Base(b.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.Path)
+}
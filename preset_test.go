@@ -0,0 +1,64 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func testPreset(temperatureC float64) DeviceConfig {
+	return DeviceConfig{
+		ThermalControlStatus:   ThermalControlStatusActive,
+		SetTemperatureC:        temperatureC,
+		DisplayTemperatureUnit: DisplayTemperatureUnitC,
+		TimeZone:               "UTC",
+		BrightnessLevel:        10,
+	}
+}
+
+func testPresetStore(t *testing.T, backend PresetBackend) {
+	var applied []float64
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			SetTemperatureC float64 `json:"set_temperature_c"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		applied = append(applied, body.SetTemperatureC)
+	}))
+	store := NewPresetStore(c, backend)
+
+	assert.NilError(t, store.Save("winter", testPreset(30)))
+	assert.NilError(t, store.Save("summer", testPreset(16)))
+
+	names, err := store.List()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, names, []string{"summer", "winter"})
+
+	assert.NilError(t, store.Apply(context.Background(), "device", "summer"))
+	assert.NilError(t, store.Apply(context.Background(), "device", "winter"))
+	assert.DeepEqual(t, applied, []float64{16, 30})
+
+	err = store.Apply(context.Background(), "device", "autumn")
+	assert.Assert(t, errors.Is(err, ErrPresetNotFound), "expected preset not found, got %v", err)
+
+	var verr *ValidationError
+	assert.Assert(t, errors.As(store.Save("broken", DeviceConfig{BrightnessLevel: 200}), &verr))
+}
+
+func TestMemoryPresetStore(t *testing.T) {
+	testPresetStore(t, nil)
+}
+
+func TestFilePresetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	testPresetStore(t, &FilePresetBackend{Path: path})
+
+	// presets survive a new backend on the same file
+	cfg, err := (&FilePresetBackend{Path: path}).Load("summer")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, cfg, testPreset(16))
+}
@@ -0,0 +1,51 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RampTemperature changes the set temperature of a Dock Pro gradually from fromF to toF degrees Fahrenheit,
+// e.g. to follow a sleep curve. fromF is set right away, followed by steps evenly spaced setpoints over
+// the duration over, the last of which is toF. Both ends must be within the temperature range of the device
+// according to its Capabilities, as known from the last Get of the device, and within the guardrails of the client,
+// see WithSetpointGuardrails, so a ramp doesn't fail halfway.
+// It blocks until toF was set, and returns the first error or ctx.Err() once ctx is cancelled
+func (c *Client) RampTemperature(ctx context.Context, deviceID string, fromF, toF float64, over time.Duration, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	if over < 0 {
+		return fmt.Errorf("duration must not be negative, got %s", over)
+	}
+	capabilities, ok := c.capabilities.lookup(deviceID)
+	if !ok {
+		capabilities = DefaultCapabilities.Fallback
+	}
+	for _, f := range []float64{fromF, toF} {
+		if f < capabilities.MinTemperatureF || f > capabilities.MaxTemperatureF {
+			return fmt.Errorf("set_temperature_f %v is outside of [%v, %v]", f, capabilities.MinTemperatureF, capabilities.MaxTemperatureF)
+		}
+		// the steps lie between both ends, so none of them can violate the guardrails if the ends don't
+		if c.temperaturePolicy != PolicyClamp {
+			f := f
+			if _, err := c.applyGuardrails(UpdateRequest{SetTemperatureF: &f}); err != nil {
+				return err
+			}
+		}
+	}
+
+	start := c.clock.Now()
+	for step := 0; step <= steps; step++ {
+		at := start.Add(over * time.Duration(step) / time.Duration(steps))
+		if err := c.sleepCtx(ctx, at.Sub(c.clock.Now())); err != nil {
+			return err
+		}
+		f := fromF + (toF-fromF)*float64(step)/float64(steps)
+		if err := c.SetTemperatureF(ctx, deviceID, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
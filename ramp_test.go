@@ -0,0 +1,99 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type rampStep struct {
+	At time.Time
+	F  float64
+}
+
+// rampHandler sends every set temperature received, along with the time on clock
+func rampHandler(clock *fakeClock, steps chan<- rampStep) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			SetTemperatureF float64 `json:"set_temperature_f"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		steps <- rampStep{At: clock.Now(), F: body.SetTemperatureF}
+	})
+}
+
+func TestRampTemperature(t *testing.T) {
+	start := time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	steps := make(chan rampStep, 10)
+	c := newTestClient(t, rampHandler(clock, steps), WithClock(clock))
+
+	done := make(chan error)
+	go func() {
+		done <- c.RampTemperature(context.Background(), "device", 60, 69, 10*time.Minute, 4)
+	}()
+
+	var got []rampStep
+	for len(got) < 5 {
+		got = append(got, <-steps)
+		if len(got) < 5 {
+			clock.BlockUntil(1)
+			clock.Advance(150 * time.Second)
+		}
+	}
+	assert.NilError(t, <-done)
+	// intermediate setpoints are rounded to whole degrees like every other set temperature
+	assert.DeepEqual(t, got, []rampStep{
+		{At: start, F: 60},
+		{At: start.Add(150 * time.Second), F: 62},
+		{At: start.Add(300 * time.Second), F: 65},
+		{At: start.Add(450 * time.Second), F: 67},
+		{At: start.Add(600 * time.Second), F: 69},
+	})
+}
+
+func TestRampTemperatureStopsOnCancel(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	steps := make(chan rampStep, 10)
+	c := newTestClient(t, rampHandler(clock, steps), WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- c.RampTemperature(ctx, "device", 80, 70, time.Hour, 10)
+	}()
+	<-steps
+	clock.BlockUntil(1)
+	cancel()
+
+	err := <-done
+	assert.Assert(t, errors.Is(err, context.Canceled), "expected cancellation, got %v", err)
+	assert.Equal(t, len(steps), 0)
+}
+
+func TestRampTemperatureValidates(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+
+	assert.ErrorContains(t, c.RampTemperature(context.Background(), "device", 60, 70, time.Hour, 0), "steps must be at least 1")
+	assert.ErrorContains(t, c.RampTemperature(context.Background(), "device", 60, 70, -time.Hour, 2), "must not be negative")
+	assert.ErrorContains(t, c.RampTemperature(context.Background(), "device", 50, 70, time.Hour, 2), "set_temperature_f 50 is outside of [55, 115]")
+	assert.ErrorContains(t, c.RampTemperature(context.Background(), "device", 60, 120, time.Hour, 2), "set_temperature_f 120 is outside of [55, 115]")
+}
+
+func TestRampTemperatureChecksGuardrails(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}), WithSetpointGuardrails(60, 80))
+
+	for _, ends := range [][2]float64{{70, 90}, {55, 70}} {
+		err := c.RampTemperature(context.Background(), "device", ends[0], ends[1], time.Hour, 4)
+		assert.Assert(t, errors.Is(err, ErrGuardrailViolation), "ramp %v: expected a guardrail violation, got %v", ends, err)
+	}
+}
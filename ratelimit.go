@@ -0,0 +1,29 @@
+package sleepme
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimiter paces outgoing requests, including retries, to rps requests per second with bursts of up to burst requests.
+// Requests wait for their turn until their context is done, keeping the client below the API rate limit
+// instead of reacting to 429s
+func WithRateLimiter(rps float64, burst int) func(*Client) error {
+	return func(c *Client) error {
+		if rps <= 0 || burst < 1 {
+			return fmt.Errorf("invalid rate limit of %v requests per second with a burst of %d", rps, burst)
+		}
+		limiter := rate.NewLimiter(rate.Limit(rps), burst)
+		c.middleware = append(c.middleware, func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if err := limiter.Wait(req.Context()); err != nil {
+					return nil, err
+				}
+				return next.RoundTrip(req)
+			})
+		})
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimiterSpacesRequests(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []time.Time
+	)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, time.Now())
+		mu.Unlock()
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithRateLimiter(20, 1))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.ListDevices(context.Background())
+			assert.Check(t, err)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(received, func(i, j int) bool { return received[i].Before(received[j]) })
+	assert.Equal(t, len(received), 5)
+	// 20 requests per second allow one request every 50ms; leave some slack for scheduling
+	assert.Assert(t, received[4].Sub(received[0]) >= 180*time.Millisecond, "requests were not paced: %s", received[4].Sub(received[0]))
+}
+
+func TestWithRateLimiterRespectsContext(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithRateLimiter(0.1, 1))
+
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.ListDevices(ctx)
+	assert.Assert(t, err != nil, "expected the second request to give up waiting")
+}
+
+func TestWithRateLimiterRejectsInvalidLimits(t *testing.T) {
+	_, err := New("token", WithRateLimiter(0, 1))
+	assert.ErrorContains(t, err, "invalid rate limit")
+	_, err = New("token", WithRateLimiter(1, 0))
+	assert.ErrorContains(t, err, "invalid rate limit")
+}
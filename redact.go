@@ -0,0 +1,38 @@
+package sleepme
+
+import (
+	"strings"
+)
+
+// redacted replaces tokens in error messages and logs
+const redacted = "[REDACTED]"
+
+// redact removes every occurrence of token from s
+func redact(s, token string) string {
+	if token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, token, redacted)
+}
+
+// redactedError hides a token in the message of err, which stays available through errors.Is and errors.As
+type redactedError struct {
+	err   error
+	token string
+}
+
+func (e *redactedError) Error() string {
+	return redact(e.err.Error(), e.token)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError ensures the message of err does not contain token
+func redactError(err error, token string) error {
+	if err == nil || token == "" || !strings.Contains(err.Error(), token) {
+		return err
+	}
+	return &redactedError{err: err, token: token}
+}
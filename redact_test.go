@@ -0,0 +1,57 @@
+package sleepme
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gotest.tools/v3/assert"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// leakyTransport fails every request with an error which includes its Authorization header
+type leakyTransport struct{}
+
+var errLeaky = errors.New("leaky transport")
+
+func (leakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%w: %s", errLeaky, req.Header.Get("Authorization"))
+}
+
+func TestErrorsNeverContainToken(t *testing.T) {
+	const token = "super-secret-token"
+	c, err := New(token)
+	assert.NilError(t, err)
+	c.Client.Transport = leakyTransport{}
+
+	_, listErr := c.ListDevices(context.Background())
+	_, getErr := c.Get(context.Background(), "device")
+	updateErr := c.Update(context.Background(), "device", UpdateRequest{})
+	_, existsErr := c.DeviceExists(context.Background(), "device")
+
+	for _, err := range []error{listErr, getErr, updateErr, existsErr} {
+		assert.Assert(t, err != nil)
+		assert.Assert(t, !strings.Contains(err.Error(), token), "error leaks the token: %s", err)
+		assert.Assert(t, strings.Contains(err.Error(), redacted), "expected a redaction marker: %s", err)
+		assert.Assert(t, errors.Is(err, errLeaky), "redaction must keep the error chain intact")
+	}
+}
+
+func TestLogsNeverContainToken(t *testing.T) {
+	const token = "super-secret-token"
+	var logs bytes.Buffer
+	c, err := New(token, WithLogger(log.New(&logs, "", 0)))
+	assert.NilError(t, err)
+
+	c.logf("request with %s failed", token)
+	assert.Equal(t, logs.String(), "request with [REDACTED] failed\n")
+}
+
+func TestRedactErrorKeepsUnrelatedErrors(t *testing.T) {
+	err := &StatusError{StatusCode: 500}
+	assert.Equal(t, redactError(err, "token"), error(err))
+	assert.NilError(t, redactError(nil, "token"))
+}
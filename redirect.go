@@ -0,0 +1,27 @@
+package sleepme
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// checkRedirect only follows redirects to the host and scheme of the original request, keeping its Authorization header.
+// net/http would drop the header when redirected elsewhere, leading to confusing authentication failures
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	orig := via[0]
+	if req.URL.Host != orig.URL.Host {
+		return fmt.Errorf("refusing redirect from %s to different host %s", orig.URL.Host, req.URL.Host)
+	}
+	// a downgrade to http would send the token in clear text
+	if req.URL.Scheme != orig.URL.Scheme {
+		return fmt.Errorf("refusing redirect from %s to %s", orig.URL.Scheme, req.URL.Scheme)
+	}
+	if auth := orig.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSameHostRedirectKeepsAuthorization(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/devices" {
+			http.Redirect(w, r, "/v2/devices", http.StatusTemporaryRedirect)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode([]Device{{ID: "device"}})
+	}))
+
+	devices, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, devices, []Device{{ID: "device"}})
+}
+
+func TestCrossHostRedirectIsRefused(t *testing.T) {
+	var leaked bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = true
+	}))
+	defer other.Close()
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/devices", http.StatusTemporaryRedirect)
+	}))
+
+	_, err := c.ListDevices(context.Background())
+	assert.ErrorContains(t, err, "refusing redirect")
+	assert.Assert(t, !leaked, "expected the other host not to be contacted")
+}
+
+func TestDowngradeRedirectIsRefused(t *testing.T) {
+	orig, err := http.NewRequest("GET", "https://api.developer.sleep.me/v1/devices", nil)
+	assert.NilError(t, err)
+	orig.Header.Set("Authorization", "Bearer test-token")
+	req, err := http.NewRequest("GET", "http://api.developer.sleep.me/v1/devices", nil)
+	assert.NilError(t, err)
+
+	err = checkRedirect(req, []*http.Request{orig})
+	assert.ErrorContains(t, err, "refusing redirect from https to http")
+	assert.Equal(t, req.Header.Get("Authorization"), "")
+}
@@ -0,0 +1,52 @@
+package sleepme
+
+import (
+	"context"
+	"math"
+)
+
+// WithSkipRedundantWrites makes SetTemperatureF, SetTemperatureC and SetTemperature fetch the device first,
+// and skip the update if it already has the requested set temperature. See SetTemperatureFIfChanged
+func WithSkipRedundantWrites() func(*Client) error {
+	return func(c *Client) error {
+		c.skipRedundantWrites = true
+		return nil
+	}
+}
+
+// SetTemperatureFIfChanged is SetTemperatureF, skipping the update if the device is already set to f degrees Fahrenheit
+// after rounding f to the steps supported by the device. It reports whether an update was sent
+func (c *Client) SetTemperatureFIfChanged(ctx context.Context, deviceID string, f float64) (bool, error) {
+	details, err := c.Get(ctx, deviceID)
+	if err != nil {
+		return false, err
+	}
+	want := f
+	if !c.strictTemperatureF {
+		step := c.temperatureStepF(deviceID)
+		want = math.Round(f/step) * step
+	}
+	if want == details.Control.SetTemperatureF {
+		return false, nil
+	}
+	if err := c.Update(ctx, deviceID, UpdateRequest{SetTemperatureF: &f}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetTemperatureCIfChanged is SetTemperatureC, skipping the update if the device is already set to celsius
+// rounded to whole degrees, as the API reports Celsius set temperatures. It reports whether an update was sent
+func (c *Client) SetTemperatureCIfChanged(ctx context.Context, deviceID string, celsius float64) (bool, error) {
+	details, err := c.Get(ctx, deviceID)
+	if err != nil {
+		return false, err
+	}
+	if int(math.Round(celsius)) == details.Control.SetTemperatureC {
+		return false, nil
+	}
+	if err := c.Update(ctx, deviceID, UpdateRequest{SetTemperatureC: &celsius}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
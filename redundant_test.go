@@ -0,0 +1,97 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+// setpointHandler serves a device set to current in unit, recording the body of every update
+func setpointHandler(unit string, current float64, updates *[]map[string]interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			*updates = append(*updates, body)
+			return
+		}
+		var details DeviceDetails
+		details.Control.DisplayTemperatureUnit = unit
+		details.Control.SetTemperatureF = current
+		details.Control.SetTemperatureC = int(current)
+		json.NewEncoder(w).Encode(details)
+	})
+}
+
+func TestSetTemperatureFIfChanged(t *testing.T) {
+	for _, tc := range []struct {
+		f       float64
+		changed bool
+	}{
+		{f: 72},
+		{f: 72.4},
+		{f: 71.5},
+		{f: 71.4, changed: true},
+		{f: 73, changed: true},
+	} {
+		var updates []map[string]interface{}
+		c := newTestClient(t, setpointHandler("f", 72, &updates))
+
+		changed, err := c.SetTemperatureFIfChanged(context.Background(), "device", tc.f)
+		assert.NilError(t, err)
+		assert.Equal(t, changed, tc.changed, "set to %v", tc.f)
+		assert.Equal(t, len(updates) == 1, tc.changed, "set to %v", tc.f)
+	}
+}
+
+func TestSetTemperatureFIfChangedStrict(t *testing.T) {
+	var updates []map[string]interface{}
+	c := newTestClient(t, setpointHandler("f", 72, &updates), WithStrictTemperatureF())
+
+	changed, err := c.SetTemperatureFIfChanged(context.Background(), "device", 72)
+	assert.NilError(t, err)
+	assert.Assert(t, !changed)
+	_, err = c.SetTemperatureFIfChanged(context.Background(), "device", 72.4)
+	assert.ErrorContains(t, err, "not a whole number of degrees")
+	assert.Equal(t, len(updates), 0)
+}
+
+func TestSetTemperatureCIfChanged(t *testing.T) {
+	for _, tc := range []struct {
+		celsius float64
+		changed bool
+	}{
+		{celsius: 22},
+		{celsius: 21.6},
+		{celsius: 22.4},
+		{celsius: 22.5, changed: true},
+		{celsius: 20, changed: true},
+	} {
+		var updates []map[string]interface{}
+		c := newTestClient(t, setpointHandler("c", 22, &updates))
+
+		changed, err := c.SetTemperatureCIfChanged(context.Background(), "device", tc.celsius)
+		assert.NilError(t, err)
+		assert.Equal(t, changed, tc.changed, "set to %v", tc.celsius)
+		assert.Equal(t, len(updates) == 1, tc.changed, "set to %v", tc.celsius)
+	}
+}
+
+func TestWithSkipRedundantWrites(t *testing.T) {
+	var updates []map[string]interface{}
+	c := newTestClient(t, setpointHandler("f", 72, &updates), WithSkipRedundantWrites())
+
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 72))
+	assert.NilError(t, c.SetTemperature(context.Background(), "device", 72.2))
+	assert.NilError(t, c.SetTemperatureC(context.Background(), "device", 22))
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 75))
+	assert.DeepEqual(t, updates, []map[string]interface{}{{"set_temperature_f": float64(75)}})
+
+	// without the option every call is sent
+	updates = nil
+	c = newTestClient(t, setpointHandler("f", 72, &updates))
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 72))
+	assert.Equal(t, len(updates), 1)
+}
@@ -0,0 +1,226 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff decides how long to wait before retrying a failed request
+type Backoff interface {
+	// NextDelay returns the delay before retry number attempt, starting at 1.
+	// resp is the response of the failed attempt, or nil if it failed without one
+	NextDelay(attempt int, resp *http.Response) time.Duration
+}
+
+// ConstantBackoff waits the same delay before every retry
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NextDelay returns b.Delay
+func (b ConstantBackoff) NextDelay(attempt int, resp *http.Response) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff doubles the delay with every retry, starting at Base and capped at Max, unless Max is zero.
+// With Jitter, each delay is picked randomly from the upper half of its range, spreading out retries of concurrent clients
+type ExponentialBackoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter bool
+}
+
+// NextDelay returns Base * 2^(attempt-1), capped at Max
+func (b ExponentialBackoff) NextDelay(attempt int, resp *http.Response) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempt && delay < maxDelay(b.Max); i++ {
+		delay *= 2
+	}
+	if delay > maxDelay(b.Max) {
+		delay = maxDelay(b.Max)
+	}
+	if b.Jitter && delay > 1 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+	return delay
+}
+
+// DecorrelatedJitterBackoff picks every delay randomly between Base and three times the previous delay, capped at Max
+// unless Max is zero.
+// The sequence restarts with the first retry of a request; concurrent requests share it
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+// NextDelay returns a random delay in [Base, min(Max, 3 * previous delay)]
+func (b *DecorrelatedJitterBackoff) NextDelay(attempt int, resp *http.Response) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if attempt <= 1 || b.prev < b.Base {
+		b.prev = b.Base
+	}
+	upper := b.prev * 3
+	if b.prev > maxDelay(b.Max)/3 {
+		upper = maxDelay(b.Max)
+	}
+	delay := b.Base
+	if upper > b.Base {
+		delay += time.Duration(rand.Int63n(int64(upper - b.Base + 1)))
+	}
+	b.prev = delay
+	return delay
+}
+
+// maxDelay returns the cap for delays given the Max of a backoff, treating zero or less as no cap.
+// Without a cap, delays stop growing at a day rather than overflowing
+func maxDelay(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 24 * time.Hour
+	}
+	return max
+}
+
+// defaultBackoff is used by clients created WithRetry but without WithBackoff
+func defaultBackoff() Backoff {
+	return ExponentialBackoff{Base: 500 * time.Millisecond, Max: 30 * time.Second, Jitter: true}
+}
+
+// WithRetry sends each request up to maxAttempts times while it fails with a transport error,
+// a truncated response, a 429 or a 5xx server error, see WithRetryClassifier. Retries are delayed according to the configured Backoff
+func WithRetry(maxAttempts int) func(*Client) error {
+	return func(c *Client) error {
+		if maxAttempts < 1 {
+			return fmt.Errorf("max attempts must be at least 1, got %d", maxAttempts)
+		}
+		c.maxAttempts = maxAttempts
+		return nil
+	}
+}
+
+// WithBackoff configures the delay between retries, see WithRetry
+func WithBackoff(b Backoff) func(*Client) error {
+	return func(c *Client) error {
+		if b == nil {
+			return errors.New("backoff must not be nil")
+		}
+		c.backoff = b
+		return nil
+	}
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns the attempt of the request sent with ctx, starting at 1 and counting up with
+// every retry, see WithRetry. Middleware can use it to tell retries from first attempts. It returns 0 for
+// contexts of anything but requests sent by a client
+func AttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+// send sends req and decodes its response into out, retrying as configured WithRetry
+func (c *Client) send(ctx context.Context, req *http.Request, out interface{}) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.WithContext(context.WithValue(req.Context(), attemptKey{}, attempt))
+		resp, err := c.roundTrip(ctx, attemptReq, out)
+		if attempt >= c.maxAttempts || !c.retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+		if _, streamed := out.(streamDecoder); streamed && errors.Is(err, ErrTruncatedResponse) {
+			return resp, err
+		}
+		// a consumed body which can't be read again would turn the retry into a different request
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, err
+		}
+
+		if err := c.sleepCtx(ctx, c.backoff.NextDelay(attempt, resp)); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// WithRetryClassifier replaces DefaultRetryClassifier in deciding which failed requests are retried, see WithRetry.
+// classify receives the response, if one was received, and the error of every failed attempt
+func WithRetryClassifier(classify func(resp *http.Response, err error) bool) func(*Client) error {
+	return func(c *Client) error {
+		if classify == nil {
+			return errors.New("retry classifier must not be nil")
+		}
+		c.retryClassifier = classify
+		return nil
+	}
+}
+
+func (c *Client) retryable(resp *http.Response, err error) bool {
+	if err == nil {
+		return false
+	}
+	if c.retryClassifier != nil {
+		return c.retryClassifier(resp, err)
+	}
+	return DefaultRetryClassifier(resp, err)
+}
+
+// DefaultRetryClassifier reports whether a request which failed with resp and err may succeed when sent again:
+// it retries transport errors, truncated responses, 429s and 5xx server errors
+func DefaultRetryClassifier(resp *http.Response, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTruncatedResponse) {
+		return true
+	}
+	if errors.Is(err, ErrResponseTooLarge) {
+		return false
+	}
+	if resp == nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads the Retry-After header in either its delay-seconds or HTTP-date form.
+// It returns zero if the header is missing, invalid or in the past
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	at, err := http.ParseTime(v)
+	if err != nil || !at.After(now) {
+		return 0
+	}
+	return at.Sub(now)
+}
@@ -0,0 +1,305 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{Delay: time.Second}
+	for attempt := 1; attempt <= 5; attempt++ {
+		assert.Equal(t, b.NextDelay(attempt, nil), time.Second)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Second, Max: 10 * time.Second}
+	var got []time.Duration
+	for attempt := 1; attempt <= 6; attempt++ {
+		got = append(got, b.NextDelay(attempt, nil))
+	}
+	assert.DeepEqual(t, got, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	})
+}
+
+func TestBackoffWithoutMax(t *testing.T) {
+	exponential := ExponentialBackoff{Base: time.Second}
+	var got []time.Duration
+	for attempt := 1; attempt <= 4; attempt++ {
+		got = append(got, exponential.NextDelay(attempt, nil))
+	}
+	assert.DeepEqual(t, got, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second})
+	assert.Equal(t, exponential.NextDelay(100, nil), 24*time.Hour)
+
+	decorrelated := &DecorrelatedJitterBackoff{Base: time.Second}
+	var grew bool
+	for i := 0; i < 100 && !grew; i++ {
+		for attempt := 1; attempt <= 6; attempt++ {
+			delay := decorrelated.NextDelay(attempt, nil)
+			assert.Assert(t, delay >= time.Second, "delay %s below base", delay)
+			grew = grew || delay > time.Second
+		}
+	}
+	assert.Assert(t, grew, "delays never grew beyond the base")
+}
+
+func TestExponentialBackoffJitter(t *testing.T) {
+	b := ExponentialBackoff{Base: time.Second, Max: 10 * time.Second, Jitter: true}
+	for i := 0; i < 100; i++ {
+		delay := b.NextDelay(3, nil)
+		assert.Assert(t, delay >= 2*time.Second && delay <= 4*time.Second, "delay %s out of range", delay)
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := &DecorrelatedJitterBackoff{Base: time.Second, Max: 20 * time.Second}
+	for i := 0; i < 100; i++ {
+		prev := time.Second
+		for attempt := 1; attempt <= 6; attempt++ {
+			delay := b.NextDelay(attempt, nil)
+			upper := 3 * prev
+			if upper > 20*time.Second {
+				upper = 20 * time.Second
+			}
+			assert.Assert(t, delay >= time.Second && delay <= upper, "attempt %d: delay %s out of range", attempt, delay)
+			prev = delay
+		}
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	var attempts int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode([]Device{{ID: "device"}})
+	}), WithRetry(3), WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+
+	devices, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, len(devices), 1)
+	assert.Equal(t, attempts, 3)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var attempts int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}), WithRetry(2), WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+
+	_, err := c.ListDevices(context.Background())
+	assert.Error(t, err, "expected 200, got 429")
+	assert.Equal(t, attempts, 2)
+}
+
+func TestNoRetryForClientErrors(t *testing.T) {
+	var attempts int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}), WithRetry(3), WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+
+	_, err := c.ListDevices(context.Background())
+	assert.Error(t, err, "expected 200, got 400")
+	assert.Equal(t, attempts, 1)
+}
+
+// truncatingHandler announces a complete body but drops the connection halfway through it for the first failures requests
+func truncatingHandler(t *testing.T, failures int) http.Handler {
+	var requests int
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		bs, err := json.Marshal(DeviceDetails{})
+		assert.NilError(t, err)
+		if requests > failures {
+			w.Write(bs)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(bs)))
+		w.Write(bs[:len(bs)/2])
+		conn, _, err := w.(http.Hijacker).Hijack()
+		assert.NilError(t, err)
+		conn.Close()
+	})
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	c := newTestClient(t, truncatingHandler(t, 1))
+
+	_, err := c.Get(context.Background(), "device")
+	assert.Assert(t, errors.Is(err, ErrTruncatedResponse), "expected a truncated response, got %v", err)
+}
+
+func TestTruncatedResponseIsRetried(t *testing.T) {
+	c := newTestClient(t, truncatingHandler(t, 1), WithRetry(2), WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+
+	_, err := c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+}
+
+func TestServiceUnavailableRetryAfter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		{retryAfter: "120", want: 2 * time.Minute},
+		{retryAfter: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{retryAfter: now.Add(-time.Minute).Format(http.TimeFormat)},
+		{retryAfter: "soon"},
+		{},
+	} {
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tc.retryAfter != "" {
+				w.Header().Set("Retry-After", tc.retryAfter)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}), WithClock(newFakeClock(now)))
+
+		_, err := c.Get(context.Background(), "device")
+		assert.Assert(t, errors.Is(err, ErrServiceUnavailable), "expected service unavailable, got %v", err)
+		var unavailable *ServiceUnavailableError
+		assert.Assert(t, errors.As(err, &unavailable))
+		assert.Equal(t, unavailable.RetryAfter, tc.want, "Retry-After %q", tc.retryAfter)
+		var statusErr *StatusError
+		assert.Assert(t, errors.As(err, &statusErr))
+		assert.Equal(t, statusErr.StatusCode, http.StatusServiceUnavailable)
+	}
+}
+
+func TestRetryClassifier(t *testing.T) {
+	statuses := []int{http.StatusRequestTimeout, http.StatusTooManyRequests}
+	var attempts int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts < len(statuses) {
+			w.WriteHeader(statuses[attempts])
+		}
+		attempts++
+		json.NewEncoder(w).Encode([]Device{})
+	})
+	// also retry 408, but never 429
+	var seen []int
+	classify := func(resp *http.Response, err error) bool {
+		seen = append(seen, resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return false
+		}
+		return resp.StatusCode == http.StatusRequestTimeout || DefaultRetryClassifier(resp, err)
+	}
+	c := newTestClient(t, handler, WithRetry(5), WithBackoff(ConstantBackoff{Delay: time.Millisecond}), WithRetryClassifier(classify))
+
+	_, err := c.ListDevices(context.Background())
+	assert.Error(t, err, "expected 200, got 429")
+	assert.Equal(t, attempts, 2)
+	assert.DeepEqual(t, seen, []int{http.StatusRequestTimeout, http.StatusTooManyRequests})
+
+	_, err = New("token", WithRetryClassifier(nil))
+	assert.ErrorContains(t, err, "must not be nil")
+}
+
+func TestRetryBackoffStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), WithRetry(3), WithBackoff(ConstantBackoff{Delay: time.Hour}), WithClock(clock))
+
+	done := make(chan error)
+	go func() {
+		_, err := c.ListDevices(ctx)
+		done <- err
+	}()
+	clock.BlockUntil(1)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.Assert(t, errors.Is(err, context.Canceled), "expected cancellation, got %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("the retry kept waiting for its backoff after being cancelled")
+	}
+}
+
+func TestAttemptFromContext(t *testing.T) {
+	var (
+		attempts []int
+		requests int
+	)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 4 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithRetry(5), WithBackoff(ConstantBackoff{Delay: time.Millisecond}), WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts = append(attempts, AttemptFromContext(req.Context()))
+			return next.RoundTrip(req)
+		})
+	}))
+
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, attempts, []int{1, 2, 3, 4})
+	assert.Equal(t, AttemptFromContext(context.Background()), 0)
+}
+
+func TestRetryResendsUpdateBody(t *testing.T) {
+	var bodies []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, err := io.ReadAll(r.Body)
+		assert.NilError(t, err)
+		bodies = append(bodies, string(bs))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}), WithRetry(3), WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+
+	status := ThermalControlStatusActive
+	zone := "Europe/Berlin"
+	err := c.Update(context.Background(), "device", UpdateRequest{ThermalControlStatus: &status, TimeZone: &zone})
+	assert.NilError(t, err)
+	assert.Equal(t, len(bodies), 3)
+	assert.Assert(t, strings.Contains(bodies[0], `"time_zone":"Europe/Berlin"`), bodies[0])
+	for _, body := range bodies[1:] {
+		assert.Equal(t, body, bodies[0])
+	}
+}
+
+func TestNoRetryForBodiesWhichCannotBeResent(t *testing.T) {
+	var attempts int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), WithRetry(3), WithBackoff(ConstantBackoff{Delay: time.Millisecond}))
+
+	body := struct{ io.Reader }{strings.NewReader(`{}`)}
+	err := c.do(context.Background(), "PATCH", devicePath("device"), body, nil)
+	assert.Assert(t, errors.Is(err, ErrServiceUnavailable), "expected service unavailable, got %v", err)
+	assert.Equal(t, attempts, 1)
+}
+
+func TestUpdateRejectsOversizedBody(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}), WithSkipValidation())
+
+	zone := strings.Repeat("x", maxRequestBytes)
+	err := c.Update(context.Background(), "device", UpdateRequest{TimeZone: &zone})
+	assert.ErrorContains(t, err, "exceeds the maximum of 65536 bytes")
+}
@@ -0,0 +1,164 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// scheduleCheckInterval bounds how long a schedule waits before checking the clock again,
+// so it fires on time even when the machine was suspended or its clock was adjusted
+const scheduleCheckInterval = time.Minute
+
+// schedules tracks the running schedules of a client so Close can cancel them
+type schedules struct {
+	mu      sync.Mutex
+	next    int
+	cancels map[int]func()
+	wg      sync.WaitGroup
+}
+
+// add registers the cancel function of a schedule. The returned function must be called once
+// the schedule returned
+func (s *schedules) add(cancel func()) (done func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancels == nil {
+		s.cancels = map[int]func(){}
+	}
+	id := s.next
+	s.next++
+	s.cancels[id] = cancel
+	s.wg.Add(1)
+	return func() {
+		s.mu.Lock()
+		delete(s.cancels, id)
+		s.mu.Unlock()
+		s.wg.Done()
+	}
+}
+
+// cancelAll cancels all schedules and waits for them to return
+func (s *schedules) cancelAll() {
+	s.mu.Lock()
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// ScheduleClimate applies r to a Dock Pro once at is reached, e.g. to warm up the bed before bedtime.
+// The returned function cancels the schedule, as does cancelling ctx or closing the client. r is prepared like by
+// Update right away, so it is rounded, clamped and validated when scheduled; an error applying it later is logged, see WithLogger
+func (c *Client) ScheduleClimate(ctx context.Context, deviceID string, at time.Time, r UpdateRequest) (cancel func(), err error) {
+	r, err = c.prepareUpdate(deviceID, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelCtx := context.WithCancel(ctx)
+	var once sync.Once
+	cancel = func() {
+		once.Do(cancelCtx)
+	}
+	done := c.schedules.add(cancel)
+
+	go func() {
+		defer done()
+		defer cancel()
+		if !c.sleepUntil(ctx, at) {
+			return
+		}
+		if err := c.updatePrepared(ctx, deviceID, r); err != nil {
+			c.logf("sleepme: failed to apply scheduled update to %s: %s", deviceID, err)
+		}
+	}()
+	return cancel, nil
+}
+
+// sleepUntil blocks until the clock reaches at, checking it at least every scheduleCheckInterval.
+// It reports false if ctx was cancelled first
+func (c *Client) sleepUntil(ctx context.Context, at time.Time) bool {
+	for {
+		remaining := at.Sub(c.clock.Now())
+		if remaining <= 0 {
+			return true
+		}
+		if remaining > scheduleCheckInterval {
+			remaining = scheduleCheckInterval
+		}
+		if c.sleepCtx(ctx, remaining) != nil {
+			return false
+		}
+	}
+}
+
+// ScheduleDaily applies r to a Dock Pro every day at hour:minute in loc, e.g. as a bedtime routine.
+// The returned function cancels the schedule, as does cancelling ctx or closing the client. r is prepared like by
+// Update right away, so it is rounded, clamped and validated when scheduled; errors applying it later are logged, see WithLogger.
+//
+// Daylight saving time transitions are handled in loc: a wall time skipped when clocks spring forward
+// fires shifted by the transition instead, e.g. 2:30 becomes 3:30. A wall time which occurs twice
+// when clocks fall back fires once, at its first occurrence
+func (c *Client) ScheduleDaily(ctx context.Context, deviceID string, hour, minute int, loc *time.Location, r UpdateRequest) (cancel func(), err error) {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return nil, fmt.Errorf("invalid time of day %02d:%02d", hour, minute)
+	}
+	if loc == nil {
+		return nil, errors.New("location must not be nil")
+	}
+	r, err = c.prepareUpdate(deviceID, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelCtx := context.WithCancel(ctx)
+	var once sync.Once
+	cancel = func() {
+		once.Do(cancelCtx)
+	}
+	done := c.schedules.add(cancel)
+
+	go func() {
+		defer done()
+		defer cancel()
+		at := nextDailyOccurrence(c.clock.Now(), hour, minute, loc)
+		for c.sleepUntil(ctx, at) {
+			if err := c.updatePrepared(ctx, deviceID, r); err != nil {
+				c.logf("sleepme: failed to apply daily update to %s: %s", deviceID, err)
+			}
+			// days missed while the machine was suspended or the update was slow are skipped rather than caught up on
+			at = nextDailyOccurrence(c.clock.Now(), hour, minute, loc)
+		}
+	}()
+	return cancel, nil
+}
+
+// nextDailyOccurrence returns the first time after after at which the wall clock in loc shows hour:minute,
+// shifting wall times skipped by a daylight saving time transition as described by ScheduleDaily
+func nextDailyOccurrence(after time.Time, hour, minute int, loc *time.Location) time.Time {
+	year, month, day := after.In(loc).Date()
+	for i := 0; ; i++ {
+		at := time.Date(year, month, day+i, hour, minute, 0, 0, loc)
+		if at.Hour() != hour || at.Minute() != minute {
+			// the wall time doesn't exist on this day. time.Date interprets it with the offset from before
+			// the transition, so move it by the offset change to land after the transition
+			_, before := at.Zone()
+			_, later := time.Date(year, month, day+i+1, hour, minute, 0, 0, loc).Zone()
+			at = at.Add(time.Duration(later-before) * time.Second)
+		}
+		if at.After(after) {
+			return at
+		}
+	}
+}
+
+// CancelSchedule deletes a schedule stored by the sleep.me API. Schedules created by ScheduleClimate or
+// ScheduleDaily run in this process and are cancelled with the function they return instead.
+// The API has no schedules to delete, so it returns ErrNotSupported
+func (c *Client) CancelSchedule(ctx context.Context, deviceID, scheduleID string) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,284 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScheduleClimate(t *testing.T) {
+	applied := make(chan struct{}, 1)
+	clock := newFakeClock(time.Date(2023, 1, 1, 21, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applied <- struct{}{}
+	}), WithClock(clock))
+
+	status := ThermalControlStatusActive
+	cancel, err := c.ScheduleClimate(context.Background(), "device", clock.Now().Add(90*time.Second), UpdateRequest{ThermalControlStatus: &status})
+	assert.NilError(t, err)
+	defer cancel()
+
+	// the schedule checks the clock every minute rather than sleeping for the whole duration
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	select {
+	case <-applied:
+		t.Fatal("applied before the scheduled time")
+	default:
+	}
+	clock.Advance(30 * time.Second)
+
+	select {
+	case <-applied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled update was not applied")
+	}
+}
+
+func TestScheduleClimateCancel(t *testing.T) {
+	applied := make(chan struct{}, 1)
+	clock := newFakeClock(time.Date(2023, 1, 1, 21, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applied <- struct{}{}
+	}), WithClock(clock))
+
+	cancel, err := c.ScheduleClimate(context.Background(), "device", clock.Now().Add(time.Minute), UpdateRequest{})
+	assert.NilError(t, err)
+	clock.BlockUntil(1)
+	cancel()
+	cancel()
+	clock.Advance(time.Hour)
+
+	select {
+	case <-applied:
+		t.Fatal("cancelled schedule was applied")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestScheduleClimateValidates(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+
+	temperature := float64(200)
+	_, err := c.ScheduleClimate(context.Background(), "device", time.Now(), UpdateRequest{SetTemperatureF: &temperature})
+	assert.ErrorContains(t, err, "outside of")
+}
+
+func TestSchedulesPrepareUpdatesUpFront(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler(), WithSetpointGuardrails(60, 80))
+
+	temperature := float64(90)
+	_, err := c.ScheduleClimate(context.Background(), "device", time.Now(), UpdateRequest{SetTemperatureF: &temperature})
+	assert.Assert(t, errors.Is(err, ErrGuardrailViolation), "expected a guardrail violation, got %v", err)
+	_, err = c.ScheduleDaily(context.Background(), "device", 21, 0, time.UTC, UpdateRequest{SetTemperatureF: &temperature})
+	assert.Assert(t, errors.Is(err, ErrGuardrailViolation), "expected a guardrail violation, got %v", err)
+
+	// out of range temperatures are clamped when scheduled, like by Update
+	applied := make(chan float64, 1)
+	clamping := newTestClient(t, recordScheduledTemperature(applied), WithTemperaturePolicy(PolicyClamp))
+	temperature = 200
+	cancel, err := clamping.ScheduleClimate(context.Background(), "device", time.Now(), UpdateRequest{SetTemperatureF: &temperature})
+	assert.NilError(t, err)
+	defer cancel()
+	select {
+	case f := <-applied:
+		assert.Equal(t, f, float64(MaxTemperatureF))
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled update was not applied")
+	}
+}
+
+// recordScheduledTemperature sends the set temperature in Fahrenheit of every update on applied
+func recordScheduledTemperature(applied chan<- float64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body UpdateRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		applied <- *body.SetTemperatureF
+	})
+}
+
+func TestNextDailyOccurrence(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NilError(t, err)
+
+	for _, tc := range []struct {
+		name         string
+		after        time.Time
+		hour, minute int
+		want         time.Time
+	}{
+		{
+			name:  "later today",
+			after: time.Date(2023, 6, 1, 20, 0, 0, 0, newYork),
+			hour:  21, minute: 30,
+			want: time.Date(2023, 6, 1, 21, 30, 0, 0, newYork),
+		},
+		{
+			name:  "tomorrow",
+			after: time.Date(2023, 6, 1, 21, 30, 0, 0, newYork),
+			hour:  21, minute: 30,
+			want: time.Date(2023, 6, 2, 21, 30, 0, 0, newYork),
+		},
+		{
+			name:  "across spring forward",
+			after: time.Date(2023, 3, 11, 22, 0, 0, 0, newYork),
+			hour:  22, minute: 0,
+			want: time.Date(2023, 3, 12, 22, 0, 0, 0, newYork),
+		},
+		{
+			name:  "skipped wall time",
+			after: time.Date(2023, 3, 11, 23, 0, 0, 0, newYork),
+			hour:  2, minute: 30,
+			want: time.Date(2023, 3, 12, 3, 30, 0, 0, newYork),
+		},
+		{
+			name:  "repeated wall time fires at first occurrence",
+			after: time.Date(2023, 11, 4, 23, 0, 0, 0, newYork),
+			hour:  1, minute: 30,
+			want: time.Date(2023, 11, 5, 5, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "repeated wall time fires once",
+			after: time.Date(2023, 11, 5, 5, 30, 0, 0, time.UTC),
+			hour:  1, minute: 30,
+			want: time.Date(2023, 11, 6, 1, 30, 0, 0, newYork),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := nextDailyOccurrence(tc.after, tc.hour, tc.minute, newYork)
+			assert.Assert(t, got.Equal(tc.want), "expected %s, got %s", tc.want, got)
+		})
+	}
+}
+
+func TestScheduleDaily(t *testing.T) {
+	applied := make(chan time.Time, 2)
+	clock := newFakeClock(time.Date(2023, 1, 1, 20, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applied <- clock.Now()
+	}), WithClock(clock))
+
+	cancel, err := c.ScheduleDaily(context.Background(), "device", 21, 0, time.UTC, UpdateRequest{})
+	assert.NilError(t, err)
+	defer cancel()
+
+	for day := 1; day <= 2; day++ {
+		want := time.Date(2023, 1, day, 21, 0, 0, 0, time.UTC)
+		for clock.Now().Before(want) {
+			clock.BlockUntil(1)
+			step := want.Sub(clock.Now())
+			if step > scheduleCheckInterval {
+				step = scheduleCheckInterval
+			}
+			clock.Advance(step)
+		}
+		select {
+		case at := <-applied:
+			assert.Assert(t, at.Equal(want), "applied at %s", at)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("update of day %d was not applied", day)
+		}
+	}
+}
+
+func TestScheduleDailySkipsMissedDays(t *testing.T) {
+	applied := make(chan time.Time, 10)
+	clock := newFakeClock(time.Date(2023, 1, 1, 20, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applied <- clock.Now()
+	}), WithClock(clock))
+
+	cancel, err := c.ScheduleDaily(context.Background(), "device", 21, 0, time.UTC, UpdateRequest{})
+	assert.NilError(t, err)
+	defer cancel()
+
+	// e.g. a suspended machine waking up days later
+	clock.BlockUntil(1)
+	clock.Advance(5 * 24 * time.Hour)
+	select {
+	case <-applied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("missed update was not applied")
+	}
+	// the schedule waits for the next day rather than catching up on the missed ones
+	clock.BlockUntil(1)
+	assert.Equal(t, len(applied), 0)
+	want := time.Date(2023, 1, 6, 21, 0, 0, 0, time.UTC)
+	for clock.Now().Before(want) {
+		clock.BlockUntil(1)
+		step := want.Sub(clock.Now())
+		if step > scheduleCheckInterval {
+			step = scheduleCheckInterval
+		}
+		clock.Advance(step)
+	}
+	select {
+	case at := <-applied:
+		assert.Assert(t, at.Equal(want), "applied at %s", at)
+	case <-time.After(5 * time.Second):
+		t.Fatal("update of the next day was not applied")
+	}
+}
+
+func TestScheduleDailyValidates(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+
+	_, err := c.ScheduleDaily(context.Background(), "device", 24, 0, time.UTC, UpdateRequest{})
+	assert.ErrorContains(t, err, "invalid time of day 24:00")
+	_, err = c.ScheduleDaily(context.Background(), "device", 21, 0, nil, UpdateRequest{})
+	assert.ErrorContains(t, err, "location must not be nil")
+}
+
+func TestCloseCancelsSchedules(t *testing.T) {
+	applied := make(chan struct{}, 2)
+	clock := newFakeClock(time.Date(2023, 1, 1, 21, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applied <- struct{}{}
+	}), WithClock(clock))
+
+	_, err := c.ScheduleClimate(context.Background(), "device", clock.Now().Add(time.Minute), UpdateRequest{})
+	assert.NilError(t, err)
+	_, err = c.ScheduleDaily(context.Background(), "device", 22, 0, time.UTC, UpdateRequest{})
+	assert.NilError(t, err)
+	clock.BlockUntil(2)
+
+	// Close waits for the schedules to return, so nothing can be applied afterwards
+	assert.NilError(t, c.Close())
+	assert.Equal(t, len(c.schedules.cancels), 0)
+	clock.Advance(time.Hour)
+	assert.Equal(t, len(applied), 0)
+}
+
+func TestScheduleClimateCancelAfterFiring(t *testing.T) {
+	applied := make(chan struct{}, 1)
+	clock := newFakeClock(time.Date(2023, 1, 1, 21, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applied <- struct{}{}
+	}), WithClock(clock))
+
+	cancel, err := c.ScheduleClimate(context.Background(), "device", clock.Now().Add(time.Minute), UpdateRequest{})
+	assert.NilError(t, err)
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	<-applied
+
+	// the schedule returns once applied, and cancelling it afterwards is a no-op
+	c.schedules.wg.Wait()
+	cancel()
+	cancel()
+	assert.Equal(t, len(c.schedules.cancels), 0)
+}
+
+func TestCancelScheduleIsNotSupported(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+
+	err := c.CancelSchedule(context.Background(), "device", "schedule")
+	assert.Assert(t, errors.Is(err, ErrNotSupported), "expected not supported, got %v", err)
+}
@@ -3,35 +3,125 @@
 package sleepme
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
+	// ProductionAPIEndpoint is the sleep.me API, used by default.
+	// sleep.me does not offer a sandbox, so every change made through it affects a real device
 	ProductionAPIEndpoint = "https://api.developer.sleep.me/v1"
+	// LocalAPIEndpoint is the conventional address of a mock API running on the local machine,
+	// for developing against without touching a real device
+	LocalAPIEndpoint = "http://localhost:8080/v1"
 )
 
+// WithAPIEndpoint sends requests to a different API, e.g. LocalAPIEndpoint
+func WithAPIEndpoint(endpoint string) func(*Client) error {
+	return func(c *Client) error {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("unsupported API endpoint %q", endpoint)
+		}
+		c.APIEndpoint = strings.TrimSuffix(endpoint, "/")
+		return nil
+	}
+}
+
+// WithLocalAccess has no effect: devices don't offer a local API, so requests keep going to APIEndpoint
+func WithLocalAccess() func(*Client) error {
+	return func(c *Client) error {
+		return nil
+	}
+}
+
 type Client struct {
 	APIEndpoint string
-	token       string
 	*http.Client
+
+	tokenSource TokenSource
+	tokenMu     sync.Mutex
+	token       string
+
+	skipValidation bool
+	coalescer      *coalescer
+	recorder       *TemperatureRecorder
+	maxAttempts    int
+	backoff        Backoff
+	defaultTimeout time.Duration
+	headers        http.Header
+
+	strictTemperatureF       bool
+	logger                   *log.Logger
+	clock                    Clock
+	warningHandler           func(Warning)
+	temperaturePolicy        TemperaturePolicy
+	defaultDevice            string
+	schedules                schedules
+	writeLocks               deviceLocks
+	capabilities             capabilityCache
+	debouncer                debouncer
+	maxResponseBytes         int64
+	preferredUnit            DisplayTemperatureUnit
+	idempotencyKeys          bool
+	retryClassifier          func(*http.Response, error) bool
+	cycleDetector            *CycleDetector
+	middleware               []Middleware
+	skipRedundantWrites      bool
+	sharedTransport          bool
+	autoFillTemperatureUnits bool
+	updateLog                *updateLog
+	guardrails               *guardrails
 }
 
-// New creates a new client and validates the provided token
+// WithHeader adds a header to every request, e.g. a key required by an API gateway.
+// Calling it multiple times adds multiple headers; headers set by the client itself can't be overridden
+func WithHeader(key, value string) func(*Client) error {
+	return func(c *Client) error {
+		key = http.CanonicalHeaderKey(key)
+		switch key {
+		case "Authorization", "Accept", "Content-Type":
+			return fmt.Errorf("header %s is managed by the client", key)
+		}
+		if c.headers == nil {
+			c.headers = http.Header{}
+		}
+		c.headers.Add(key, value)
+		return nil
+	}
+}
+
+// New creates a new client and validates the provided token.
+// Surrounding whitespace and a leading "Bearer " are trimmed from token, so it may be pasted as a whole header value
 func New(token string, opts ...func(*Client) error) (*Client, error) {
 	c := &Client{
-		token:       token,
-		APIEndpoint: ProductionAPIEndpoint,
-		Client:      &http.Client{},
+		token:            normalizeToken(token),
+		APIEndpoint:      ProductionAPIEndpoint,
+		Client:           &http.Client{CheckRedirect: checkRedirect},
+		maxAttempts:      1,
+		backoff:          defaultBackoff(),
+		clock:            realClock{},
+		maxResponseBytes: DefaultMaxResponseBytes,
 	}
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
 			return nil, err
 		}
 	}
+	c.logInsecure()
 	return c, nil
 }
 
@@ -44,29 +134,43 @@ type Device struct {
 
 // ListDevices lists all Dock Pro units available with the active user
 func (c *Client) ListDevices(ctx context.Context) ([]Device, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/devices", c.APIEndpoint), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	req = req.WithContext(ctx)
-
-	resp, err := c.Client.Do(req)
-	if err != nil {
+	// decoded as a whole rather than through ListDevicesFunc, so truncated responses can be retried
+	var res []Device
+	if err := c.do(ctx, "GET", "/devices", nil, &res); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected 200, got %d", resp.StatusCode)
-	}
+	return res, nil
+}
 
-	var res []Device
-	return res, json.NewDecoder(resp.Body).Decode(&res)
+// ListDevicesFunc calls fn for every Dock Pro unit available with the active user, decoding them one at a time.
+// This saves memory over ListDevices for accounts with many devices. An error returned by fn stops the listing and is returned.
+// As fn may already have seen some devices, a truncated response is not retried even by clients created WithRetry
+func (c *Client) ListDevicesFunc(ctx context.Context, fn func(Device) error) error {
+	return c.do(ctx, "GET", "/devices", nil, streamDecoder(func(dec *json.Decoder) error {
+		tok, err := dec.Token()
+		if err != nil || tok == nil {
+			return err
+		}
+		if tok != json.Delim('[') {
+			return &structureError{want: "a list of devices", got: tok}
+		}
+		for dec.More() {
+			var device Device
+			if err := dec.Decode(&device); err != nil {
+				return err
+			}
+			if err := fn(device); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}))
 }
 
-// DeviceDetails contains all the details available via the API
+// DeviceDetails contains all the details available via the API.
+// The set temperature in the unit shown on the display is authoritative: Get derives the other one from it,
+// so Control.SetTemperatureC and Control.SetTemperatureF always agree
 type DeviceDetails struct {
 	About struct {
 		FirmwareVersion string `json:"firmware_version"`
@@ -78,45 +182,106 @@ type DeviceDetails struct {
 	} `json:"about"`
 
 	Control struct {
-		BrightnessLevel        int    `json:"brightness_level"`
-		DisplayTemperatureUnit string `json:"display_temperature_unit"`
-		SetTemperatureC        int    `json:"set_temperature_c"`
-		SetTemperatureF        int    `json:"set_temperature_f"`
-		ThermalControlStatus   string `json:"thermal_control_status"`
-		TimeZone               string `json:"time_zone"`
+		BrightnessLevel        int     `json:"brightness_level"`
+		DisplayTemperatureUnit string  `json:"display_temperature_unit"`
+		SetTemperatureC        int     `json:"set_temperature_c"`
+		SetTemperatureF        float64 `json:"set_temperature_f"`
+		ThermalControlStatus   string  `json:"thermal_control_status"`
+		TimeZone               string  `json:"time_zone"`
 	} `json:"control"`
 
 	Status struct {
 		IsConnected       bool    `json:"is_connected"`
 		IsWaterLow        bool    `json:"is_water_low"`
 		WaterLevel        int     `json:"water_level"`
-		WaterTemperatureF int     `json:"water_temperature_f"`
+		WaterTemperatureF float64 `json:"water_temperature_f"`
 		WaterTemperatureC float64 `json:"water_temperature_c"`
+		// FaultCodes lists the active faults of devices reporting them, see Faults
+		FaultCodes []FaultCode `json:"fault_codes,omitempty"`
 	} `json:"status"`
 }
 
 // Get fetches details for a specific Dock Pro unit
 func (c *Client) Get(ctx context.Context, deviceID string) (*DeviceDetails, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/devices/%s", c.APIEndpoint, deviceID), nil)
-	if err != nil {
-		return nil, err
+	var res DeviceDetails
+	if err := c.do(ctx, "GET", devicePath(deviceID), nil, &res); err != nil {
+		return nil, deviceError(deviceID, err)
 	}
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	req = req.WithContext(ctx)
+	c.processDetails(deviceID, &res)
+	return &res, nil
+}
+
+// processDetails normalizes freshly decoded details and remembers the capabilities of the device
+func (c *Client) processDetails(deviceID string, details *DeviceDetails) {
+	// a proxy or newer API version might report the unit in upper case
+	details.Control.DisplayTemperatureUnit = strings.ToLower(details.Control.DisplayTemperatureUnit)
+	c.checkDetails(deviceID, details)
+	details.syncSetTemperatures()
+	c.capabilities.remember(deviceID, details)
+}
+
+// DeviceResult is the outcome of fetching the details of one device, see GetAll
+type DeviceResult struct {
+	Device  Device
+	Details *DeviceDetails
+	Err     error
+}
 
-	resp, err := c.Client.Do(req)
+// maxConcurrentDetails bounds the details fetched at once by GetAll, keeping large
+// accounts from bursting through the rate limit of the API
+const maxConcurrentDetails = 4
+
+// GetAll fetches the details of every device of the account, up to four at a time, in the order of ListDevices.
+// A device which fails to fetch has its Err set rather than failing the whole call;
+// an error is only returned if the devices can't be listed
+func (c *Client) GetAll(ctx context.Context) ([]DeviceResult, error) {
+	devices, err := c.ListDevices(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected 200, got %d", resp.StatusCode)
+	results := make([]DeviceResult, len(devices))
+	sem := make(chan struct{}, maxConcurrentDetails)
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		i, device := i, device
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			details, err := c.Get(ctx, device.ID)
+			results[i] = DeviceResult{Device: device, Details: details, Err: err}
+		}()
 	}
+	wg.Wait()
+	return results, nil
+}
 
+// devicePath returns the API path of a device, escaping IDs which contain special characters
+func devicePath(deviceID string) string {
+	return fmt.Sprintf("/devices/%s", url.PathEscape(deviceID))
+}
+
+// GetResponse is Get, additionally returning the raw response for inspecting its status and headers.
+// The response is returned whenever one was received, even alongside an error. Its body is already consumed
+func (c *Client) GetResponse(ctx context.Context, deviceID string) (*DeviceDetails, *http.Response, error) {
 	var res DeviceDetails
-	return &res, json.NewDecoder(resp.Body).Decode(&res)
+	resp, err := c.doResponse(ctx, "GET", devicePath(deviceID), nil, &res)
+	if err != nil {
+		return nil, resp, deviceError(deviceID, err)
+	}
+	c.processDetails(deviceID, &res)
+	return &res, resp, nil
+}
+
+// DeviceExists reports whether the API knows a Dock Pro, without decoding its details.
+// The API does not support HEAD requests, so the details are requested but discarded
+func (c *Client) DeviceExists(ctx context.Context, deviceID string) (bool, error) {
+	err := deviceError(deviceID, c.do(ctx, "GET", devicePath(deviceID), nil, nil))
+	if errors.Is(err, ErrDeviceNotFound) {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 // ThermalControlStatus configures if the unit is active or not
@@ -146,31 +311,333 @@ type UpdateRequest struct {
 	SetTemperatureC        *float64                `json:"set_temperature_c,omitempty"`
 	DisplayTemperatureUnit *DisplayTemperatureUnit `json:"display_temperature_unit,omitempty"`
 	TimeZone               *string                 `json:"time_zone,omitempty"`
+	BrightnessLevel        *int                    `json:"brightness_level,omitempty"`
 }
 
-// Update reconfigures a Dock Pro
+// Update reconfigures a Dock Pro. The request is validated first, unless the client was created WithSkipValidation.
+// Clients created WithCoalesce merge the request with other updates to the same device before sending it.
+// Concurrent writes to the same device are sent one after another, so they can't interleave; writes to
+// different devices and all reads are sent in parallel
 func (c *Client) Update(ctx context.Context, deviceID string, r UpdateRequest) error {
-	bs := bytes.Buffer{}
-	if err := json.NewEncoder(&bs).Encode(r); err != nil {
+	r, err := c.prepareUpdate(deviceID, r)
+	if err != nil {
+		return err
+	}
+	return c.updatePrepared(ctx, deviceID, r)
+}
+
+// updatePrepared is Update for requests which already went through prepareUpdate
+func (c *Client) updatePrepared(ctx context.Context, deviceID string, r UpdateRequest) error {
+	if c.coalescer != nil {
+		return c.coalescer.update(ctx, deviceID, r)
+	}
+	return c.update(ctx, deviceID, r)
+}
+
+// UpdateWithResult is Update, additionally returning the state of the Dock Pro after the change.
+// The state is taken from the response if the API echoes the details, and fetched with Get otherwise.
+// The update is sent right away, even by clients created WithCoalesce
+func (c *Client) UpdateWithResult(ctx context.Context, deviceID string, r UpdateRequest) (*DeviceDetails, error) {
+	r, err := c.prepareUpdate(deviceID, r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := encodeBody(r.raw())
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := c.writeLocks.lock(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var echo json.RawMessage
+	err = c.do(ctx, "PATCH", devicePath(deviceID), body, &echo)
+	if errors.Is(err, io.EOF) {
+		c.recordUpdate(deviceID, r)
+		return c.Get(ctx, deviceID)
+	}
+	if err != nil {
+		return nil, deviceError(deviceID, err)
+	}
+	c.recordUpdate(deviceID, r)
+	if !echoesDetails(echo) {
+		return c.Get(ctx, deviceID)
+	}
+	var res DeviceDetails
+	if err := json.Unmarshal(echo, &res); err != nil {
+		return nil, c.decodeError(err, echo)
+	}
+	c.processDetails(deviceID, &res)
+	return &res, nil
+}
+
+// echoesDetails reports whether the response to an update contains the details of the device. Other
+// responses, e.g. {} or just the changed control fields, would decode into mostly zero details
+func echoesDetails(echo json.RawMessage) bool {
+	var probe struct {
+		Control map[string]json.RawMessage `json:"control"`
+	}
+	return json.Unmarshal(echo, &probe) == nil && len(probe.Control) > 0
+}
+
+// prepareUpdate rounds, checks guardrails, clamps and validates a request as configured for the client
+func (c *Client) prepareUpdate(deviceID string, r UpdateRequest) (UpdateRequest, error) {
+	r, err := c.roundTemperatureF(deviceID, r)
+	if err != nil {
+		return r, err
+	}
+	if r, err = c.applyGuardrails(r); err != nil {
+		return r, err
+	}
+	if c.temperaturePolicy == PolicyClamp {
+		r = c.clampTemperatures(r)
+	}
+	if c.autoFillTemperatureUnits {
+		r = c.autoFillTemperatures(deviceID, r)
+	}
+	if !c.skipValidation {
+		if err := r.validate(c.temperaturePolicy != PolicyPassthrough); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func (c *Client) update(ctx context.Context, deviceID string, r UpdateRequest) error {
+	unlock, err := c.writeLocks.lock(ctx, deviceID)
+	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest("PATCH", fmt.Sprintf("%s/devices/%s", c.APIEndpoint, deviceID), &bs)
+	defer unlock()
+	return c.patch(ctx, deviceID, r)
+}
+
+// patch sends a prepared request. Callers must hold the write lock of the device
+func (c *Client) patch(ctx context.Context, deviceID string, r UpdateRequest) error {
+	body, err := encodeBody(r.raw())
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	if err := c.do(ctx, "PATCH", devicePath(deviceID), body, nil); err != nil {
+		return deviceError(deviceID, err)
+	}
+	c.recordUpdate(deviceID, r)
+	return nil
+}
+
+// StatusError is returned when the API responds with an unexpected status code
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("expected 200, got %d", e.StatusCode)
+}
+
+// ErrServiceUnavailable is matched by errors for 503 responses, e.g. during maintenance, see ServiceUnavailableError
+var ErrServiceUnavailable = errors.New("service unavailable")
+
+// ServiceUnavailableError is returned when the API responds with a 503. It unwraps to a *StatusError
+type ServiceUnavailableError struct {
+	// RetryAfter is how long the API asked clients to wait, from its Retry-After header. Zero if it didn't say
+	RetryAfter time.Duration
+}
+
+func (e *ServiceUnavailableError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("expected 200, got %d, retry after %s", http.StatusServiceUnavailable, e.RetryAfter)
+	}
+	return fmt.Sprintf("expected 200, got %d", http.StatusServiceUnavailable)
+}
+
+// Is makes ServiceUnavailableError match ErrServiceUnavailable
+func (e *ServiceUnavailableError) Is(target error) bool {
+	return target == ErrServiceUnavailable
+}
+
+func (e *ServiceUnavailableError) Unwrap() error {
+	return &StatusError{StatusCode: http.StatusServiceUnavailable}
+}
+
+// ErrNotSupported is returned for features the sleep.me API does not offer
+var ErrNotSupported = errors.New("not supported by the sleep.me API")
+
+// ErrDeviceNotFound is matched by errors for devices unknown to the API, see DeviceNotFoundError
+var ErrDeviceNotFound = errors.New("device not found")
+
+// DeviceNotFoundError is returned when the API does not know the requested device
+type DeviceNotFoundError struct {
+	DeviceID string
+}
+
+func (e *DeviceNotFoundError) Error() string {
+	return fmt.Sprintf("device %q not found", e.DeviceID)
+}
+
+// Is makes DeviceNotFoundError match ErrDeviceNotFound
+func (e *DeviceNotFoundError) Is(target error) bool {
+	return target == ErrDeviceNotFound
+}
+
+// deviceError maps a 404 for a device specific request to a DeviceNotFoundError
+func deviceError(deviceID string, err error) error {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return &DeviceNotFoundError{DeviceID: deviceID}
+	}
+	return err
+}
+
+// do sends a request to the API and expects a 200 in return.
+// A body is sent as JSON, and the response is decoded into out unless it is nil
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	_, err := c.doResponse(ctx, method, path, body, out)
+	return err
+}
+
+// doResponse is do, additionally returning the response whenever one was received. Its body is already closed
+func (c *Client) doResponse(ctx context.Context, method, path string, body io.Reader, out interface{}) (resp *http.Response, err error) {
+	// don't start a request for a caller which already gave up
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	token, err := c.currentToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens := []string{token}
+	// no error may leak a token, no matter which layer produced it
+	defer func() {
+		for _, token := range tokens {
+			err = redactError(err, token)
+		}
+	}()
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.APIEndpoint, path), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if out != nil {
+		req.Header.Set("Accept", "application/json")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	if err := c.setIdempotencyKey(req); err != nil {
+		return nil, err
+	}
 	req = req.WithContext(ctx)
 
-	resp, err := c.Client.Do(req)
+	for refreshes := 0; ; refreshes++ {
+		resp, err = c.send(ctx, req, out)
+		if !isUnauthorized(err) || c.tokenSource == nil || refreshes >= maxTokenRefreshes {
+			return resp, err
+		}
+		if body != nil && req.GetBody == nil {
+			return resp, err
+		}
+		if token, err = c.refreshToken(ctx, token); err != nil {
+			return resp, err
+		}
+		tokens = append(tokens, token)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// roundTrip sends req once and decodes its response, which must be a 200, into out unless it is nil.
+// The body of the response is closed before returning
+func (c *Client) roundTrip(ctx context.Context, req *http.Request, out interface{}) (*http.Response, error) {
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return resp, &ServiceUnavailableError{RetryAfter: parseRetryAfter(resp.Header, c.clock.Now())}
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("expected 200, got %d", resp.StatusCode)
+		return resp, &StatusError{StatusCode: resp.StatusCode}
 	}
 
-	return nil
+	// responses without a body, like those to updates, succeed when no result is expected.
+	// Callers expecting one get a DecodeError wrapping io.EOF whatever the content type, see UpdateWithResult
+	if out == nil {
+		return resp, nil
+	}
+	if resp.ContentLength == 0 {
+		return resp, c.decodeError(io.EOF, nil)
+	}
+	if err := checkContentType(resp); err != nil {
+		return resp, err
+	}
+	var snippet snippetWriter
+	dec := json.NewDecoder(io.TeeReader(resp.Body, &snippet))
+	if decode, ok := out.(streamDecoder); ok {
+		err = decode(dec)
+	} else {
+		err = dec.Decode(out)
+	}
+	return resp, c.decodeError(truncated(err), snippet.buf)
+}
+
+// ErrTruncatedResponse is matched by errors for responses which ended before their body was complete,
+// e.g. because the connection dropped. Clients created WithRetry retry them
+var ErrTruncatedResponse = errors.New("truncated response")
+
+// truncated marks errors caused by an incomplete response body with ErrTruncatedResponse
+func truncated(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v", ErrTruncatedResponse, err)
+	}
+	return err
+}
+
+// streamDecoder can be passed to do instead of a value, to consume the response incrementally
+type streamDecoder func(dec *json.Decoder) error
+
+// ErrUnexpectedContentType is matched by errors for responses which are not JSON, see ContentTypeError
+var ErrUnexpectedContentType = errors.New("unexpected content type")
+
+// ContentTypeError is returned when a response is not JSON, e.g. because a captive portal or proxy answered instead of the API
+type ContentTypeError struct {
+	ContentType string
+	// Snippet is the beginning of the response body
+	Snippet string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("expected a JSON response, got %q: %s", e.ContentType, e.Snippet)
+}
+
+// Is makes ContentTypeError match ErrUnexpectedContentType
+func (e *ContentTypeError) Is(target error) bool {
+	return target == ErrUnexpectedContentType
+}
+
+// checkContentType ensures a response is JSON before decoding it. Responses without a content type are assumed to be JSON
+func checkContentType(resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, snippetLength))
+	return &ContentTypeError{ContentType: contentType, Snippet: string(snippet)}
 }
@@ -2,9 +2,16 @@ package sleepme
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"gotest.tools/v3/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 var (
@@ -31,9 +38,354 @@ func TestClient(t *testing.T) {
 	details, err := c.Get(context.Background(), devices[0].ID)
 	assert.NilError(t, err)
 
-	desiredTemperatureF := float64(details.Control.SetTemperatureF)
+	desiredTemperatureF := details.Control.SetTemperatureF
 	err = c.Update(context.Background(), devices[0].ID, UpdateRequest{
 		SetTemperatureF: &desiredTemperatureF,
 	})
 	assert.NilError(t, err)
 }
+
+// newTestClient starts a mock API server backed by handler and returns a client pointed at it
+func newTestClient(t *testing.T, handler http.Handler, opts ...func(*Client) error) *Client {
+	t.Helper()
+
+	// like the API, respond with JSON unless the handler says otherwise
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New("test-token", append([]func(*Client) error{WithAPIEndpoint(srv.URL)}, opts...)...)
+	assert.NilError(t, err, "failed to create client")
+	return c
+}
+
+func TestUpdateAbortsOnContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := c.Update(ctx, "device", UpdateRequest{})
+	assert.Assert(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got %v", err)
+	assert.Assert(t, time.Since(started) < time.Second, "update did not abort promptly")
+}
+
+func TestUpdateSkipsRequestForCancelledContext(t *testing.T) {
+	var called bool
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.Update(ctx, "device", UpdateRequest{})
+	assert.Assert(t, errors.Is(err, context.Canceled), "expected cancellation, got %v", err)
+	assert.Assert(t, !called, "expected no request to be sent")
+}
+
+func TestUnknownDeviceIsNotFound(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+
+	_, err := c.Get(context.Background(), "unknown")
+	assert.Assert(t, errors.Is(err, ErrDeviceNotFound), "expected not found, got %v", err)
+	var notFound *DeviceNotFoundError
+	assert.Assert(t, errors.As(err, &notFound))
+	assert.Equal(t, notFound.DeviceID, "unknown")
+
+	err = c.Update(context.Background(), "unknown", UpdateRequest{})
+	assert.Assert(t, errors.Is(err, ErrDeviceNotFound), "expected not found, got %v", err)
+	assert.Error(t, err, `device "unknown" not found`)
+}
+
+func TestOtherStatusIsNotNotFound(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	_, err := c.Get(context.Background(), "device")
+	assert.Assert(t, !errors.Is(err, ErrDeviceNotFound))
+	assert.Error(t, err, "expected 200, got 401")
+}
+
+func TestDeviceExists(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/devices/known":
+			w.Write([]byte(`not even json`))
+		case "/devices/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	exists, err := c.DeviceExists(context.Background(), "known")
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+
+	exists, err = c.DeviceExists(context.Background(), "unknown")
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+
+	_, err = c.DeviceExists(context.Background(), "broken")
+	assert.Error(t, err, "expected 200, got 500")
+}
+
+func TestDeviceIDsAreEscaped(t *testing.T) {
+	var paths []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}))
+
+	_, err := c.Get(context.Background(), "a/b c")
+	assert.NilError(t, err)
+	assert.NilError(t, c.Update(context.Background(), "a/b c", UpdateRequest{}))
+
+	assert.DeepEqual(t, paths, []string{"/devices/a%2Fb%20c", "/devices/a%2Fb%20c"})
+}
+
+func TestWithAPIEndpoint(t *testing.T) {
+	c, err := New("token")
+	assert.NilError(t, err)
+	assert.Equal(t, c.APIEndpoint, ProductionAPIEndpoint)
+
+	c, err = New("token", WithAPIEndpoint(LocalAPIEndpoint+"/"))
+	assert.NilError(t, err)
+	assert.Equal(t, c.APIEndpoint, LocalAPIEndpoint)
+
+	_, err = New("token", WithAPIEndpoint("localhost:8080"))
+	assert.ErrorContains(t, err, "unsupported API endpoint")
+}
+
+func TestGetResponse(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		if r.URL.Path == "/devices/unknown" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}))
+
+	details, resp, err := c.GetResponse(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.Assert(t, details != nil)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, resp.Header.Get("X-Request-Id"), "abc")
+
+	details, resp, err = c.GetResponse(context.Background(), "unknown")
+	assert.Assert(t, errors.Is(err, ErrDeviceNotFound))
+	assert.Assert(t, details == nil)
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+}
+
+func TestHTMLResponseIsUnexpectedContentType(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body>Please log in to the hotel wifi</body></html>"))
+	}))
+
+	_, err := c.ListDevices(context.Background())
+	assert.Assert(t, errors.Is(err, ErrUnexpectedContentType), "expected unexpected content type, got %v", err)
+	var contentTypeErr *ContentTypeError
+	assert.Assert(t, errors.As(err, &contentTypeErr))
+	assert.Equal(t, contentTypeErr.ContentType, "text/html; charset=utf-8")
+	assert.Equal(t, contentTypeErr.Snippet, "<html><body>Please log in to the hotel wifi</body></html>")
+}
+
+func TestWithHeader(t *testing.T) {
+	var seen []http.Header
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header)
+		if r.URL.Path == "/devices" {
+			json.NewEncoder(w).Encode([]Device{})
+			return
+		}
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}), WithHeader("x-gateway-key", "secret"), WithHeader("X-Tenant", "a"), WithHeader("X-Tenant", "b"))
+
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	_, err = c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{}))
+
+	assert.Equal(t, len(seen), 3)
+	for _, h := range seen {
+		assert.Equal(t, h.Get("X-Gateway-Key"), "secret")
+		assert.DeepEqual(t, h.Values("X-Tenant"), []string{"a", "b"})
+		assert.Equal(t, h.Get("Authorization"), "Bearer test-token")
+	}
+}
+
+func TestWithHeaderRejectsManagedHeaders(t *testing.T) {
+	for _, key := range []string{"authorization", "Accept", "Content-Type"} {
+		_, err := New("token", WithHeader(key, "value"))
+		assert.ErrorContains(t, err, "is managed by the client")
+	}
+}
+
+func TestUpdateWithResultFromEcho(t *testing.T) {
+	var gets int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			gets++
+		}
+		var details DeviceDetails
+		details.Control.SetTemperatureF = 72
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	temperature := float64(72)
+	details, err := c.UpdateWithResult(context.Background(), "device", UpdateRequest{SetTemperatureF: &temperature})
+	assert.NilError(t, err)
+	assert.Equal(t, details.Control.SetTemperatureF, float64(72))
+	assert.Equal(t, gets, 0, "expected the echo to be used")
+}
+
+func TestUpdateWithResultFallsBackToGet(t *testing.T) {
+	var gets int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			return
+		}
+		gets++
+		var details DeviceDetails
+		details.Control.SetTemperatureF = 65
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	temperature := float64(65)
+	details, err := c.UpdateWithResult(context.Background(), "device", UpdateRequest{SetTemperatureF: &temperature})
+	assert.NilError(t, err)
+	assert.Equal(t, details.Control.SetTemperatureF, float64(65))
+	assert.Equal(t, gets, 1)
+}
+
+func TestUpdateWithResultIgnoresOtherEchoes(t *testing.T) {
+	for _, echo := range []string{`{}`, `null`, `{"set_temperature_f":65}`, `{"control":{}}`, `[]`} {
+		var gets int
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PATCH" {
+				io.WriteString(w, echo)
+				return
+			}
+			gets++
+			var details DeviceDetails
+			details.Control.SetTemperatureF = 65
+			details.Control.TimeZone = "Europe/Berlin"
+			json.NewEncoder(w).Encode(details)
+		}))
+
+		temperature := float64(65)
+		details, err := c.UpdateWithResult(context.Background(), "device", UpdateRequest{SetTemperatureF: &temperature})
+		assert.NilError(t, err, "echo %s", echo)
+		assert.Equal(t, details.Control.TimeZone, "Europe/Berlin", "echo %s", echo)
+		assert.Equal(t, gets, 1, "echo %s", echo)
+	}
+}
+
+func TestEmptyUpdateResponse(t *testing.T) {
+	var gets int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			// a proxy may label an empty body with any content type
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		gets++
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}))
+
+	level := 10
+	assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{BrightnessLevel: &level}))
+	_, err := c.UpdateWithResult(context.Background(), "device", UpdateRequest{BrightnessLevel: &level})
+	assert.NilError(t, err)
+	assert.Equal(t, gets, 1)
+}
+
+func TestListDevicesFunc(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Device{{ID: "a"}, {ID: "b"}, {ID: "c"}})
+	}))
+
+	var ids []string
+	err := c.ListDevicesFunc(context.Background(), func(device Device) error {
+		ids = append(ids, device.ID)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ids, []string{"a", "b", "c"})
+
+	stop := errors.New("stop")
+	ids = nil
+	err = c.ListDevicesFunc(context.Background(), func(device Device) error {
+		ids = append(ids, device.ID)
+		return stop
+	})
+	assert.Assert(t, errors.Is(err, stop))
+	assert.DeepEqual(t, ids, []string{"a"})
+}
+
+func TestListDevicesEmpty(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+
+	devices, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, devices, []Device{})
+}
+
+func benchmarkDevicesClient(b *testing.B) *Client {
+	devices := make([]Device, 1000)
+	for i := range devices {
+		devices[i] = Device{ID: fmt.Sprintf("device-%d", i), Name: "Bedroom", Attachments: []string{"bed"}}
+	}
+	bs, err := json.Marshal(devices)
+	assert.NilError(b, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bs)
+	}))
+	b.Cleanup(srv.Close)
+	c, err := New("token", WithAPIEndpoint(srv.URL))
+	assert.NilError(b, err)
+	return c
+}
+
+func BenchmarkListDevices(b *testing.B) {
+	c := benchmarkDevicesClient(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.ListDevices(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListDevicesFunc(b *testing.B) {
+	c := benchmarkDevicesClient(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.ListDevicesFunc(context.Background(), func(Device) error { return nil }); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Package sleepmetest provides a fake sleep.me client for testing code depending on sleepme.DeviceAPI
+package sleepmetest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/nicolai86/sleepme"
+)
+
+// Call is a method call received by a FakeClient
+type Call struct {
+	// Method is the name of the DeviceAPI method, e.g. "Update"
+	Method string
+	// Args are the arguments following the context
+	Args []interface{}
+}
+
+// FakeClient implements sleepme.DeviceAPI with devices held in memory. Updates are validated like
+// the client does by default, and applied to the details of the device.
+// The zero value is an account without devices. It is safe for concurrent use
+type FakeClient struct {
+	mu      sync.Mutex
+	devices []sleepme.Device
+	details map[string]sleepme.DeviceDetails
+	errs    map[string]error
+	calls   []Call
+}
+
+var _ sleepme.DeviceAPI = (*FakeClient)(nil)
+
+// AddDevice adds a device with the given details to the account, replacing a device with the same ID
+func (f *FakeClient) AddDevice(device sleepme.Device, details sleepme.DeviceDetails) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.details == nil {
+		f.details = map[string]sleepme.DeviceDetails{}
+	}
+	for i, d := range f.devices {
+		if d.ID == device.ID {
+			f.devices = append(f.devices[:i], f.devices[i+1:]...)
+			break
+		}
+	}
+	f.devices = append(f.devices, device)
+	f.details[device.ID] = details
+}
+
+// SetDetails replaces the details of a device, e.g. to simulate the water warming up.
+// It panics for devices which weren't added
+func (f *FakeClient) SetDetails(deviceID string, details sleepme.DeviceDetails) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.details[deviceID]; !ok {
+		panic(fmt.Sprintf("sleepmetest: unknown device %q", deviceID))
+	}
+	f.details[deviceID] = details
+}
+
+// Details returns the current details of a device, e.g. to assert on the result of updates
+func (f *FakeClient) Details(deviceID string) (sleepme.DeviceDetails, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	details, ok := f.details[deviceID]
+	return details, ok
+}
+
+// SetError makes every call of method, e.g. "Get", fail with err until it is reset with a nil error.
+// Failing calls are recorded, but change nothing
+func (f *FakeClient) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = map[string]error{}
+	}
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// Calls returns all calls received so far, oldest first
+func (f *FakeClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// record adds a call and returns the error injected for its method. Callers must hold f.mu
+func (f *FakeClient) record(method string, args ...interface{}) error {
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	return f.errs[method]
+}
+
+// ListDevices returns the devices added to the fake
+func (f *FakeClient) ListDevices(ctx context.Context) ([]sleepme.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListDevices"); err != nil {
+		return nil, err
+	}
+	return append([]sleepme.Device{}, f.devices...), nil
+}
+
+// Get returns a copy of the details of a device, or a *sleepme.DeviceNotFoundError
+func (f *FakeClient) Get(ctx context.Context, deviceID string) (*sleepme.DeviceDetails, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Get", deviceID); err != nil {
+		return nil, err
+	}
+	return f.get(deviceID)
+}
+
+func (f *FakeClient) get(deviceID string) (*sleepme.DeviceDetails, error) {
+	details, ok := f.details[deviceID]
+	if !ok {
+		return nil, &sleepme.DeviceNotFoundError{DeviceID: deviceID}
+	}
+	return &details, nil
+}
+
+// Update validates r and applies it to the details of a device
+func (f *FakeClient) Update(ctx context.Context, deviceID string, r sleepme.UpdateRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("Update", deviceID, r); err != nil {
+		return err
+	}
+	return f.update(deviceID, r)
+}
+
+// UpdateWithResult is Update, returning the details after the change
+func (f *FakeClient) UpdateWithResult(ctx context.Context, deviceID string, r sleepme.UpdateRequest) (*sleepme.DeviceDetails, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("UpdateWithResult", deviceID, r); err != nil {
+		return nil, err
+	}
+	if err := f.update(deviceID, r); err != nil {
+		return nil, err
+	}
+	return f.get(deviceID)
+}
+
+func (f *FakeClient) update(deviceID string, r sleepme.UpdateRequest) error {
+	details, ok := f.details[deviceID]
+	if !ok {
+		return &sleepme.DeviceNotFoundError{DeviceID: deviceID}
+	}
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.ThermalControlStatus != nil {
+		details.Control.ThermalControlStatus = string(*r.ThermalControlStatus)
+	}
+	if r.DisplayTemperatureUnit != nil {
+		details.Control.DisplayTemperatureUnit = string(*r.DisplayTemperatureUnit)
+	}
+	if r.TimeZone != nil {
+		details.Control.TimeZone = *r.TimeZone
+	}
+	if r.BrightnessLevel != nil {
+		details.Control.BrightnessLevel = *r.BrightnessLevel
+	}
+	// like the device, keep both set temperatures in sync
+	switch {
+	case r.SetTemperatureF != nil:
+		details.Control.SetTemperatureF = math.Round(*r.SetTemperatureF)
+		details.Control.SetTemperatureC = int(math.Round(sleepme.CelsiusFromFahrenheit(*r.SetTemperatureF)))
+	case r.SetTemperatureC != nil:
+		details.Control.SetTemperatureC = int(math.Round(*r.SetTemperatureC))
+		details.Control.SetTemperatureF = math.Round(sleepme.FahrenheitFromCelsius(*r.SetTemperatureC))
+	}
+	f.details[deviceID] = details
+	return nil
+}
+
+// DeviceExists reports whether a device was added
+func (f *FakeClient) DeviceExists(ctx context.Context, deviceID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DeviceExists", deviceID); err != nil {
+		return false, err
+	}
+	_, ok := f.details[deviceID]
+	return ok, nil
+}
+
+// DeviceByName finds a device by name like sleepme.Client.DeviceByName
+func (f *FakeClient) DeviceByName(ctx context.Context, name string) (*sleepme.Device, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DeviceByName", name); err != nil {
+		return nil, err
+	}
+	var exact, folded []sleepme.Device
+	for _, device := range f.devices {
+		if device.Name == name {
+			exact = append(exact, device)
+		}
+		if strings.EqualFold(device.Name, name) {
+			folded = append(folded, device)
+		}
+	}
+	matches := folded
+	if len(exact) > 0 {
+		matches = exact
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: no device named %q", sleepme.ErrDeviceNotFound, name)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("%w: %d devices named %q", sleepme.ErrAmbiguousName, len(matches), name)
+}
+
+// SetTemperatureF sets the set temperature of a device to temp degrees Fahrenheit, rounded to whole degrees
+func (f *FakeClient) SetTemperatureF(ctx context.Context, deviceID string, temp float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("SetTemperatureF", deviceID, temp); err != nil {
+		return err
+	}
+	return f.update(deviceID, sleepme.UpdateRequest{SetTemperatureF: &temp})
+}
+
+// SetTemperatureC sets the set temperature of a device in degrees Celsius
+func (f *FakeClient) SetTemperatureC(ctx context.Context, deviceID string, celsius float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("SetTemperatureC", deviceID, celsius); err != nil {
+		return err
+	}
+	return f.update(deviceID, sleepme.UpdateRequest{SetTemperatureC: &celsius})
+}
+
+// SetClimate sets the thermal control status and set temperature of a device at once
+func (f *FakeClient) SetClimate(ctx context.Context, deviceID string, status sleepme.ThermalControlStatus, temp float64, unit sleepme.DisplayTemperatureUnit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("SetClimate", deviceID, status, temp, unit); err != nil {
+		return err
+	}
+	r := sleepme.UpdateRequest{ThermalControlStatus: &status}
+	switch unit {
+	case sleepme.DisplayTemperatureUnitC:
+		r.SetTemperatureC = &temp
+	case sleepme.DisplayTemperatureUnitF:
+		r.SetTemperatureF = &temp
+	default:
+		return fmt.Errorf("unknown temperature unit %q", unit)
+	}
+	return f.update(deviceID, r)
+}
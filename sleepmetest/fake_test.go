@@ -0,0 +1,106 @@
+package sleepmetest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nicolai86/sleepme"
+	"github.com/nicolai86/sleepme/sleepmetest"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+// warmUp is code under test, depending on sleepme.DeviceAPI instead of *sleepme.Client
+func warmUp(ctx context.Context, api sleepme.DeviceAPI, name string) error {
+	device, err := api.DeviceByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	return api.SetClimate(ctx, device.ID, sleepme.ThermalControlStatusActive, 80, sleepme.DisplayTemperatureUnitF)
+}
+
+func Example() {
+	fake := &sleepmetest.FakeClient{}
+	var details sleepme.DeviceDetails
+	details.Control.ThermalControlStatus = "standby"
+	fake.AddDevice(sleepme.Device{ID: "dock", Name: "Bedroom"}, details)
+
+	if err := warmUp(context.Background(), fake, "bedroom"); err != nil {
+		fmt.Println(err)
+	}
+	details, _ = fake.Details("dock")
+	fmt.Println(details.Control.ThermalControlStatus, details.Control.SetTemperatureF, details.Control.SetTemperatureC)
+	for _, call := range fake.Calls() {
+		fmt.Println(call.Method, call.Args)
+	}
+	// Output:
+	// active 80 27
+	// DeviceByName [bedroom]
+	// SetClimate [dock active 80 f]
+}
+
+func TestFakeClientUpdate(t *testing.T) {
+	fake := &sleepmetest.FakeClient{}
+	fake.AddDevice(sleepme.Device{ID: "dock"}, sleepme.DeviceDetails{})
+
+	level := 40
+	celsius := 21.4
+	details, err := fake.UpdateWithResult(context.Background(), "dock", sleepme.UpdateRequest{BrightnessLevel: &level, SetTemperatureC: &celsius})
+	assert.NilError(t, err)
+	assert.Equal(t, details.Control.BrightnessLevel, 40)
+	assert.Equal(t, details.Control.SetTemperatureC, 21)
+	assert.Equal(t, details.Control.SetTemperatureF, float64(71))
+
+	// returned details are copies
+	details.Control.BrightnessLevel = 0
+	current, err := fake.Get(context.Background(), "dock")
+	assert.NilError(t, err)
+	assert.Equal(t, current.Control.BrightnessLevel, 40)
+
+	var verr *sleepme.ValidationError
+	assert.Assert(t, errors.As(fake.SetTemperatureF(context.Background(), "dock", 200), &verr))
+	assert.Assert(t, errors.Is(fake.SetTemperatureF(context.Background(), "missing", 70), sleepme.ErrDeviceNotFound))
+	_, err = fake.Get(context.Background(), "missing")
+	assert.Assert(t, errors.Is(err, sleepme.ErrDeviceNotFound))
+	exists, err := fake.DeviceExists(context.Background(), "missing")
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+}
+
+func TestFakeClientSetError(t *testing.T) {
+	fake := &sleepmetest.FakeClient{}
+	fake.AddDevice(sleepme.Device{ID: "dock", Name: "Bedroom"}, sleepme.DeviceDetails{})
+	unavailable := errors.New("service unavailable")
+	fake.SetError("SetClimate", unavailable)
+
+	err := warmUp(context.Background(), fake, "Bedroom")
+	assert.Assert(t, errors.Is(err, unavailable), "expected the injected error, got %v", err)
+	details, _ := fake.Details("dock")
+	assert.Equal(t, details.Control.ThermalControlStatus, "", "failing calls change nothing")
+	_, err = fake.ListDevices(context.Background())
+	assert.NilError(t, err, "errors are injected per method")
+
+	fake.SetError("SetClimate", nil)
+	assert.NilError(t, warmUp(context.Background(), fake, "Bedroom"))
+	assert.Equal(t, len(fake.Calls()), 5)
+}
+
+func TestFakeClientDeviceByName(t *testing.T) {
+	fake := &sleepmetest.FakeClient{}
+	fake.AddDevice(sleepme.Device{ID: "a", Name: "Left"}, sleepme.DeviceDetails{})
+	fake.AddDevice(sleepme.Device{ID: "b", Name: "left"}, sleepme.DeviceDetails{})
+	fake.AddDevice(sleepme.Device{ID: "c", Name: "Right"}, sleepme.DeviceDetails{})
+
+	device, err := fake.DeviceByName(context.Background(), "left")
+	assert.NilError(t, err)
+	assert.Equal(t, device.ID, "b")
+	_, err = fake.DeviceByName(context.Background(), "LEFT")
+	assert.Assert(t, errors.Is(err, sleepme.ErrAmbiguousName))
+	_, err = fake.DeviceByName(context.Background(), "middle")
+	assert.Assert(t, errors.Is(err, sleepme.ErrDeviceNotFound))
+
+	fake.AddDevice(sleepme.Device{ID: "c", Name: "Middle"}, sleepme.DeviceDetails{})
+	devices, err := fake.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, len(devices), 3, "adding a device again replaces it")
+}
@@ -0,0 +1,28 @@
+package sleepme
+
+import (
+	"context"
+	"time"
+)
+
+// DeviceSnapshot pairs the details of a Dock Pro with the time they were fetched.
+// The API does not report when a device last checked in, so this is the best indication of how fresh the details are
+type DeviceSnapshot struct {
+	Details   *DeviceDetails
+	FetchedAt time.Time
+}
+
+// Age returns how long before now the snapshot was fetched. FetchedAt is taken from the clock of the client,
+// see WithClock, so now must come from the same clock
+func (s DeviceSnapshot) Age(now time.Time) time.Duration {
+	return now.Sub(s.FetchedAt)
+}
+
+// GetSnapshot is Get, additionally recording when the details were received
+func (c *Client) GetSnapshot(ctx context.Context, deviceID string) (*DeviceSnapshot, error) {
+	details, err := c.Get(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceSnapshot{Details: details, FetchedAt: c.clock.Now()}, nil
+}
@@ -0,0 +1,39 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetSnapshot(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var details DeviceDetails
+		details.Status.IsConnected = true
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	before := time.Now()
+	snapshot, err := c.GetSnapshot(context.Background(), "device")
+	after := time.Now()
+	assert.NilError(t, err)
+
+	assert.Assert(t, snapshot.Details.Status.IsConnected)
+	assert.Assert(t, !snapshot.FetchedAt.Before(before) && !snapshot.FetchedAt.After(after))
+	assert.Assert(t, snapshot.Age(time.Now()) >= 0)
+}
+
+func TestSnapshotAge(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}), WithClock(clock))
+
+	snapshot, err := c.GetSnapshot(context.Background(), "device")
+	assert.NilError(t, err)
+	clock.Advance(3 * time.Minute)
+	assert.Equal(t, snapshot.Age(clock.Now()), 3*time.Minute)
+}
@@ -0,0 +1,286 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// WithStrictTemperatureF rejects Fahrenheit set temperatures the device doesn't support, instead of rounding them.
+// The Dock Pro only supports whole degrees Fahrenheit, and rounds other values unpredictably
+func WithStrictTemperatureF() func(*Client) error {
+	return func(c *Client) error {
+		c.strictTemperatureF = true
+		return nil
+	}
+}
+
+// WithLogger logs noteworthy but non-fatal events, e.g. a set temperature being rounded
+func WithLogger(l *log.Logger) func(*Client) error {
+	return func(c *Client) error {
+		c.logger = l
+		return nil
+	}
+}
+
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+	c.tokenMu.Lock()
+	token := c.token
+	c.tokenMu.Unlock()
+	c.logger.Print(redact(fmt.Sprintf(format, args...), token))
+}
+
+// SetTemperatureF changes the set temperature of a Dock Pro to f degrees Fahrenheit.
+// f is rounded to the nearest whole degree unless the client was created WithStrictTemperatureF.
+// Half degrees are kept for devices which support them according to their Capabilities, as known
+// from the last Get of the device. Clients created WithSkipRedundantWrites skip setting the current set temperature
+func (c *Client) SetTemperatureF(ctx context.Context, deviceID string, f float64) error {
+	if c.skipRedundantWrites {
+		_, err := c.SetTemperatureFIfChanged(ctx, deviceID, f)
+		return err
+	}
+	return c.Update(ctx, deviceID, UpdateRequest{SetTemperatureF: &f})
+}
+
+// SetTemperatureC changes the set temperature of a Dock Pro to degrees Celsius.
+// Clients created WithSkipRedundantWrites skip setting the current set temperature
+func (c *Client) SetTemperatureC(ctx context.Context, deviceID string, celsius float64) error {
+	if c.skipRedundantWrites {
+		_, err := c.SetTemperatureCIfChanged(ctx, deviceID, celsius)
+		return err
+	}
+	return c.Update(ctx, deviceID, UpdateRequest{SetTemperatureC: &celsius})
+}
+
+// TurnOn sets a Dock Pro to active, so it heats or cools to its set temperature.
+// Clients created WithCycleDetector record the call
+func (c *Client) TurnOn(ctx context.Context, deviceID string) error {
+	return c.setThermalControlStatus(ctx, deviceID, ThermalControlStatusActive)
+}
+
+// TurnOff sets a Dock Pro to standby. Clients created WithCycleDetector record the call
+func (c *Client) TurnOff(ctx context.Context, deviceID string) error {
+	return c.setThermalControlStatus(ctx, deviceID, ThermalControlStatusStandby)
+}
+
+func (c *Client) setThermalControlStatus(ctx context.Context, deviceID string, status ThermalControlStatus) error {
+	if c.cycleDetector != nil {
+		c.cycleDetector.Record(deviceID, status == ThermalControlStatusActive, c.clock.Now())
+	}
+	return c.Update(ctx, deviceID, UpdateRequest{ThermalControlStatus: &status})
+}
+
+// SetTemperature changes the set temperature of a Dock Pro to temp, given in the unit preferred by the
+// client, see WithPreferredUnitFromAccount. Clients without a preferred unit use Fahrenheit
+func (c *Client) SetTemperature(ctx context.Context, deviceID string, temp float64) error {
+	if c.preferredUnit == DisplayTemperatureUnitC {
+		return c.SetTemperatureC(ctx, deviceID, temp)
+	}
+	return c.SetTemperatureF(ctx, deviceID, temp)
+}
+
+// roundTemperatureF rounds the Fahrenheit set temperature of r to the steps supported by the device,
+// or rejects it under WithStrictTemperatureF
+func (c *Client) roundTemperatureF(deviceID string, r UpdateRequest) (UpdateRequest, error) {
+	if r.SetTemperatureF == nil {
+		return r, nil
+	}
+	step := c.temperatureStepF(deviceID)
+	f := *r.SetTemperatureF
+	rounded := math.Round(f/step) * step
+	if rounded == f {
+		return r, nil
+	}
+	if c.strictTemperatureF && step == 1 {
+		return r, fmt.Errorf("set_temperature_f %v is not a whole number of degrees", f)
+	}
+	if c.strictTemperatureF {
+		return r, fmt.Errorf("set_temperature_f %v is not a multiple of %v degrees", f, step)
+	}
+	c.logf("sleepme: rounding set_temperature_f %v to %v, the device only supports steps of %v degrees Fahrenheit", f, rounded, step)
+	c.warn(WarningTemperatureRounded, "set_temperature_f %v was rounded to %v", f, rounded)
+	r.SetTemperatureF = &rounded
+	return r, nil
+}
+
+// WithAutoFillTemperatureUnits makes Update send requests setting the temperature in only one unit with both,
+// converting it to the other unit, so the device receives a consistent pair.
+// Converted values are rounded like the device does: to whole degrees Celsius, and to the steps of
+// the device in Fahrenheit, see SetTemperatureF
+func WithAutoFillTemperatureUnits() func(*Client) error {
+	return func(c *Client) error {
+		c.autoFillTemperatureUnits = true
+		return nil
+	}
+}
+
+// autoFillTemperatures sets the set temperature of r in the unit missing from it, see WithAutoFillTemperatureUnits
+func (c *Client) autoFillTemperatures(deviceID string, r UpdateRequest) UpdateRequest {
+	switch {
+	case r.SetTemperatureF != nil && r.SetTemperatureC == nil:
+		celsius := math.Round(CelsiusFromFahrenheit(*r.SetTemperatureF))
+		r.SetTemperatureC = &celsius
+	case r.SetTemperatureC != nil && r.SetTemperatureF == nil:
+		step := c.temperatureStepF(deviceID)
+		f := math.Round(FahrenheitFromCelsius(*r.SetTemperatureC)/step) * step
+		r.SetTemperatureF = &f
+	}
+	return r
+}
+
+// temperatureStepF returns the steps of Fahrenheit set temperatures supported by the device
+func (c *Client) temperatureStepF(deviceID string) float64 {
+	if capabilities, ok := c.capabilities.lookup(deviceID); ok && capabilities.SupportsHalfDegreeF {
+		return 0.5
+	}
+	return 1
+}
+
+// SetClimate changes the thermal control status and set temperature of a Dock Pro in a single request.
+// temp is given in unit, which does not change the unit used by the display. Out of range temperatures
+// are handled according to the TemperaturePolicy of the client
+func (c *Client) SetClimate(ctx context.Context, deviceID string, status ThermalControlStatus, temp float64, unit DisplayTemperatureUnit) error {
+	r := UpdateRequest{ThermalControlStatus: &status}
+	switch unit {
+	case DisplayTemperatureUnitC:
+		r.SetTemperatureC = &temp
+	case DisplayTemperatureUnitF:
+		r.SetTemperatureF = &temp
+	default:
+		return fmt.Errorf("unknown temperature unit %q", unit)
+	}
+	return c.Update(ctx, deviceID, r)
+}
+
+// DetailsInUnit are the details of a Dock Pro with its temperatures in Unit, whatever the display shows, see GetInUnit
+type DetailsInUnit struct {
+	// Details are the details as returned by Get
+	Details *DeviceDetails
+	Unit    DisplayTemperatureUnit
+	// SetTemperature is converted from the set temperature shown on the display without rounding
+	SetTemperature   float64
+	WaterTemperature float64
+}
+
+// TemperatureDelta is DeviceDetails.TemperatureDelta in Unit
+func (d *DetailsInUnit) TemperatureDelta() float64 {
+	return d.SetTemperature - d.WaterTemperature
+}
+
+// GetInUnit is Get, additionally converting the set and water temperatures to unit. This saves callers from
+// handling both display units, and from the set temperature in Celsius being rounded to whole degrees
+func (c *Client) GetInUnit(ctx context.Context, deviceID string, unit DisplayTemperatureUnit) (*DetailsInUnit, error) {
+	if !unit.valid() {
+		return nil, fmt.Errorf("unknown temperature unit %q", unit)
+	}
+	details, err := c.Get(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	res := &DetailsInUnit{Details: details, Unit: unit}
+	display := DisplayTemperatureUnit(details.Control.DisplayTemperatureUnit)
+	if unit == DisplayTemperatureUnitC {
+		res.SetTemperature = float64(details.Control.SetTemperatureC)
+		if display == DisplayTemperatureUnitF {
+			res.SetTemperature = CelsiusFromFahrenheit(details.Control.SetTemperatureF)
+		}
+		res.WaterTemperature = details.Status.WaterTemperatureC
+		return res, nil
+	}
+	res.SetTemperature = details.Control.SetTemperatureF
+	if display == DisplayTemperatureUnitC {
+		res.SetTemperature = FahrenheitFromCelsius(float64(details.Control.SetTemperatureC))
+	}
+	res.WaterTemperature = details.Status.WaterTemperatureF
+	return res, nil
+}
+
+// FahrenheitFromCelsius converts a temperature from Celsius to Fahrenheit
+func FahrenheitFromCelsius(celsius float64) float64 {
+	return celsius*9/5 + 32
+}
+
+// CelsiusFromFahrenheit converts a temperature from Fahrenheit to Celsius
+func CelsiusFromFahrenheit(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+// syncSetTemperatures derives the set temperature in the unit not shown on the display from the one which is,
+// as the API rounds both independently and they can disagree
+func (d *DeviceDetails) syncSetTemperatures() {
+	switch DisplayTemperatureUnit(d.Control.DisplayTemperatureUnit) {
+	case DisplayTemperatureUnitC:
+		d.Control.SetTemperatureF = math.Round(FahrenheitFromCelsius(float64(d.Control.SetTemperatureC)))
+	case DisplayTemperatureUnitF:
+		d.Control.SetTemperatureC = int(math.Round(CelsiusFromFahrenheit(d.Control.SetTemperatureF)))
+	}
+}
+
+// TemperaturePolicy decides how set temperatures outside of the range supported by the Dock Pro are handled
+type TemperaturePolicy int
+
+const (
+	// PolicyError rejects out of range temperatures with a *ValidationError. This is the default
+	PolicyError TemperaturePolicy = iota
+	// PolicyClamp moves out of range temperatures to the closest supported one, raising WarningTemperatureClamped
+	PolicyClamp
+	// PolicyPassthrough sends out of range temperatures to the API as they are
+	PolicyPassthrough
+)
+
+// WithTemperaturePolicy configures how Update and all temperature setters handle out of range temperatures
+func WithTemperaturePolicy(policy TemperaturePolicy) func(*Client) error {
+	return func(c *Client) error {
+		switch policy {
+		case PolicyError, PolicyClamp, PolicyPassthrough:
+		default:
+			return fmt.Errorf("unknown temperature policy %d", policy)
+		}
+		c.temperaturePolicy = policy
+		return nil
+	}
+}
+
+// clampTemperatures moves the set temperatures of r into the range supported by the Dock Pro
+func (c *Client) clampTemperatures(r UpdateRequest) UpdateRequest {
+	if r.SetTemperatureF != nil {
+		r.SetTemperatureF = c.clamp("set_temperature_f", *r.SetTemperatureF, MinTemperatureF, MaxTemperatureF)
+	}
+	if r.SetTemperatureC != nil {
+		r.SetTemperatureC = c.clamp("set_temperature_c", *r.SetTemperatureC, MinTemperatureC, MaxTemperatureC)
+	}
+	return r
+}
+
+func (c *Client) clamp(field string, v, min, max float64) *float64 {
+	clamped := math.Max(min, math.Min(max, v))
+	if clamped != v {
+		c.warn(WarningTemperatureClamped, "%s %v was clamped to %v", field, v, clamped)
+	}
+	return &clamped
+}
+
+// WarmupProgress reports between 0 and 1 how far the water moved from startF towards the set temperature,
+// e.g. to show "bed is 80% warm". It works for cooling as well. startF is usually the water temperature
+// when the Dock Pro was turned on
+func (d *DeviceDetails) WarmupProgress(startF float64) float64 {
+	target := d.Control.SetTemperatureF
+	if target == startF {
+		return 1
+	}
+	progress := (d.Status.WaterTemperatureF - startF) / (target - startF)
+	return math.Max(0, math.Min(1, progress))
+}
+
+// TemperatureDelta returns the set temperature minus the water temperature in the unit shown on the display.
+// A positive delta means the water still needs to warm up, a negative one that it needs to cool down
+func (d *DeviceDetails) TemperatureDelta() float64 {
+	if DisplayTemperatureUnit(d.Control.DisplayTemperatureUnit) == DisplayTemperatureUnitC {
+		return float64(d.Control.SetTemperatureC) - d.Status.WaterTemperatureC
+	}
+	return d.Control.SetTemperatureF - d.Status.WaterTemperatureF
+}
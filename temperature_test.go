@@ -0,0 +1,335 @@
+package sleepme
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// recordTemperatureF returns a handler storing the Fahrenheit set temperature of every update
+func recordTemperatureF(sent *[]float64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			SetTemperatureF float64 `json:"set_temperature_f"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		*sent = append(*sent, body.SetTemperatureF)
+	})
+}
+
+func TestSetTemperatureFRounds(t *testing.T) {
+	var (
+		sent []float64
+		logs bytes.Buffer
+	)
+	c := newTestClient(t, recordTemperatureF(&sent), WithLogger(log.New(&logs, "", 0)))
+
+	for _, f := range []float64{72, 72.4, 72.5, 71.6} {
+		assert.NilError(t, c.SetTemperatureF(context.Background(), "device", f))
+	}
+	assert.DeepEqual(t, sent, []float64{72, 72, 73, 72})
+	assert.Equal(t, bytes.Count(logs.Bytes(), []byte("rounding set_temperature_f")), 3)
+}
+
+func TestSetTemperatureFStrict(t *testing.T) {
+	var sent []float64
+	c := newTestClient(t, recordTemperatureF(&sent), WithStrictTemperatureF())
+
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 72))
+	assert.ErrorContains(t, c.SetTemperatureF(context.Background(), "device", 72.5), "not a whole number of degrees")
+	assert.DeepEqual(t, sent, []float64{72})
+}
+
+func TestRoundingDoesNotModifyCallerRequest(t *testing.T) {
+	var sent []float64
+	c := newTestClient(t, recordTemperatureF(&sent))
+
+	f := 72.5
+	assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{SetTemperatureF: &f}))
+	assert.Equal(t, f, 72.5)
+}
+
+func TestSetClimate(t *testing.T) {
+	var updates []map[string]interface{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		updates = append(updates, body)
+	})
+	c := newTestClient(t, handler)
+
+	assert.NilError(t, c.SetClimate(context.Background(), "device", ThermalControlStatusActive, 21.5, DisplayTemperatureUnitC))
+	assert.NilError(t, c.SetClimate(context.Background(), "device", ThermalControlStatusStandby, 70, DisplayTemperatureUnitF))
+	assert.DeepEqual(t, updates, []map[string]interface{}{
+		{"thermal_control_status": "active", "set_temperature_c": 21.5},
+		{"thermal_control_status": "standby", "set_temperature_f": float64(70)},
+	})
+
+	assert.ErrorContains(t, c.SetClimate(context.Background(), "device", "on", 70, DisplayTemperatureUnitF), "unknown thermal_control_status")
+	assert.ErrorContains(t, c.SetClimate(context.Background(), "device", ThermalControlStatusActive, 200, DisplayTemperatureUnitF), "outside of")
+	assert.ErrorContains(t, c.SetClimate(context.Background(), "device", ThermalControlStatusActive, 20, "k"), "unknown temperature unit")
+	assert.Equal(t, len(updates), 2)
+
+	// like Update, SetClimate follows the temperature policy and WithSkipValidation
+	updates = nil
+	clamping := newTestClient(t, handler, WithTemperaturePolicy(PolicyClamp))
+	assert.NilError(t, clamping.SetClimate(context.Background(), "device", ThermalControlStatusActive, 200, DisplayTemperatureUnitF))
+	skipping := newTestClient(t, handler, WithSkipValidation())
+	assert.NilError(t, skipping.SetClimate(context.Background(), "device", "on", 70, DisplayTemperatureUnitF))
+	assert.DeepEqual(t, updates, []map[string]interface{}{
+		{"thermal_control_status": "active", "set_temperature_f": float64(MaxTemperatureF)},
+		{"thermal_control_status": "on", "set_temperature_f": float64(70)},
+	})
+}
+
+func TestGetSyncsSetTemperatures(t *testing.T) {
+	for _, tc := range []struct {
+		unit  string
+		c     int
+		f     float64
+		wantC int
+		wantF float64
+	}{
+		{unit: "c", c: 20, f: 67, wantC: 20, wantF: 68},
+		{unit: "c", c: 13, f: 0, wantC: 13, wantF: 55},
+		{unit: "f", c: 21, f: 72, wantC: 22, wantF: 72},
+		{unit: "f", c: 0, f: 115, wantC: 46, wantF: 115},
+	} {
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var details DeviceDetails
+			details.Control.DisplayTemperatureUnit = tc.unit
+			details.Control.SetTemperatureC = tc.c
+			details.Control.SetTemperatureF = tc.f
+			json.NewEncoder(w).Encode(details)
+		}))
+
+		details, err := c.Get(context.Background(), "device")
+		assert.NilError(t, err)
+		assert.Equal(t, details.Control.SetTemperatureC, tc.wantC, "unit %s", tc.unit)
+		assert.Equal(t, details.Control.SetTemperatureF, tc.wantF, "unit %s", tc.unit)
+	}
+}
+
+func TestTemperaturePolicy(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		var sent []float64
+		c := newTestClient(t, recordTemperatureF(&sent))
+
+		var verr *ValidationError
+		assert.Assert(t, errors.As(c.SetTemperatureF(context.Background(), "device", 120), &verr))
+		assert.Equal(t, len(sent), 0)
+	})
+
+	t.Run("clamp", func(t *testing.T) {
+		var (
+			sent     []float64
+			warnings []Warning
+		)
+		c := newTestClient(t, recordTemperatureF(&sent), WithTemperaturePolicy(PolicyClamp), WithWarningHandler(func(w Warning) {
+			warnings = append(warnings, w)
+		}))
+
+		assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 120))
+		assert.NilError(t, c.SetClimate(context.Background(), "device", ThermalControlStatusActive, 40, DisplayTemperatureUnitF))
+		assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 70))
+		assert.DeepEqual(t, sent, []float64{115, 55, 70})
+		assert.Equal(t, len(warnings), 2)
+		assert.Equal(t, warnings[0].Code, WarningTemperatureClamped)
+		assert.Equal(t, warnings[0].Message, "set_temperature_f 120 was clamped to 115")
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		var sent []float64
+		c := newTestClient(t, recordTemperatureF(&sent), WithTemperaturePolicy(PolicyPassthrough))
+
+		assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 120))
+		assert.NilError(t, c.SetClimate(context.Background(), "device", ThermalControlStatusActive, 40, DisplayTemperatureUnitF))
+		assert.DeepEqual(t, sent, []float64{120, 40})
+		assert.ErrorContains(t, c.SetClimate(context.Background(), "device", "on", 70, DisplayTemperatureUnitF), "unknown thermal_control_status")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := New("token", WithTemperaturePolicy(TemperaturePolicy(7)))
+		assert.ErrorContains(t, err, "unknown temperature policy 7")
+	})
+}
+
+func TestWarmupProgress(t *testing.T) {
+	for _, tc := range []struct {
+		start, water, set float64
+		want              float64
+	}{
+		{start: 60, water: 60, set: 80, want: 0},
+		{start: 60, water: 76, set: 80, want: 0.8},
+		{start: 60, water: 85, set: 80, want: 1},
+		{start: 60, water: 55, set: 80, want: 0},
+		{start: 80, water: 65, set: 60, want: 0.75},
+		{start: 70, water: 72, set: 70, want: 1},
+	} {
+		var details DeviceDetails
+		details.Status.WaterTemperatureF = tc.water
+		details.Control.SetTemperatureF = tc.set
+		assert.Equal(t, details.WarmupProgress(tc.start), tc.want, "start %v, water %v, set %v", tc.start, tc.water, tc.set)
+	}
+}
+
+func TestSetTemperatureFHalfDegrees(t *testing.T) {
+	table := DefaultCapabilities
+	t.Cleanup(func() { DefaultCapabilities = table })
+	halfDegrees := table.Fallback
+	halfDegrees.SupportsHalfDegreeF = true
+	DefaultCapabilities = CapabilityTable{
+		Rules:    append([]CapabilityRule{{ModelPrefix: "DP", MinFirmware: "2.0.0", Capabilities: halfDegrees}}, table.Rules...),
+		Fallback: table.Fallback,
+	}
+
+	sent := map[string][]float64{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deviceID := strings.TrimPrefix(r.URL.Path, "/devices/")
+		if r.Method == "PATCH" {
+			var body struct {
+				SetTemperatureF float64 `json:"set_temperature_f"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			sent[deviceID] = append(sent[deviceID], body.SetTemperatureF)
+			return
+		}
+		details := DeviceDetails{}
+		details.About.Model = "DP999NA"
+		details.About.FirmwareVersion = map[string]string{"new": "2.1.0", "old": "1.9.0"}[deviceID]
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	for _, deviceID := range []string{"new", "old"} {
+		_, err := c.Get(context.Background(), deviceID)
+		assert.NilError(t, err)
+	}
+	for _, deviceID := range []string{"new", "old", "unknown"} {
+		for _, f := range []float64{72.5, 72.4, 71.8} {
+			assert.NilError(t, c.SetTemperatureF(context.Background(), deviceID, f))
+		}
+	}
+	assert.DeepEqual(t, sent, map[string][]float64{
+		"new":     {72.5, 72.5, 72},
+		"old":     {73, 72, 72},
+		"unknown": {73, 72, 72},
+	})
+
+	strict := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		details := DeviceDetails{}
+		details.About.Model = "DP999NA"
+		details.About.FirmwareVersion = "2.1.0"
+		json.NewEncoder(w).Encode(details)
+	}), WithStrictTemperatureF())
+	_, err := strict.Get(context.Background(), "new")
+	assert.NilError(t, err)
+	assert.NilError(t, strict.SetTemperatureF(context.Background(), "new", 72.5))
+	assert.ErrorContains(t, strict.SetTemperatureF(context.Background(), "new", 72.25), "not a multiple of 0.5 degrees")
+}
+
+func TestTemperatureDelta(t *testing.T) {
+	for _, tc := range []struct {
+		unit  string
+		setC  int
+		water float64
+		setF  float64
+		want  float64
+	}{
+		{unit: "c", setC: 22, water: 19.5, want: 2.5},
+		{unit: "c", setC: 18, water: 19.5, want: -1.5},
+		{unit: "f", setF: 72, water: 65, want: 7},
+		{unit: "f", setF: 60, water: 65, want: -5},
+	} {
+		var details DeviceDetails
+		details.Control.DisplayTemperatureUnit = tc.unit
+		details.Control.SetTemperatureC = tc.setC
+		details.Control.SetTemperatureF = tc.setF
+		details.Status.WaterTemperatureC = tc.water
+		details.Status.WaterTemperatureF = tc.water
+		assert.Equal(t, details.TemperatureDelta(), tc.want, "unit %s", tc.unit)
+	}
+}
+
+func TestWithAutoFillTemperatureUnits(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	for _, tc := range []struct {
+		req  UpdateRequest
+		want map[string]interface{}
+	}{
+		{req: UpdateRequest{SetTemperatureF: f(72)}, want: map[string]interface{}{"set_temperature_f": float64(72), "set_temperature_c": float64(22)}},
+		{req: UpdateRequest{SetTemperatureF: f(70.4)}, want: map[string]interface{}{"set_temperature_f": float64(70), "set_temperature_c": float64(21)}},
+		{req: UpdateRequest{SetTemperatureC: f(21.5)}, want: map[string]interface{}{"set_temperature_c": 21.5, "set_temperature_f": float64(71)}},
+		{req: UpdateRequest{SetTemperatureC: f(13)}, want: map[string]interface{}{"set_temperature_c": float64(13), "set_temperature_f": float64(55)}},
+		{req: UpdateRequest{SetTemperatureF: f(60), SetTemperatureC: f(30)}, want: map[string]interface{}{"set_temperature_f": float64(60), "set_temperature_c": float64(30)}},
+	} {
+		for _, autoFill := range []bool{true, false} {
+			var body map[string]interface{}
+			opts := []func(*Client) error{WithSkipValidation()}
+			if autoFill {
+				opts = append(opts, WithAutoFillTemperatureUnits())
+			}
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&body)
+			}), opts...)
+
+			assert.NilError(t, c.Update(context.Background(), "device", tc.req))
+			if autoFill {
+				assert.DeepEqual(t, body, tc.want)
+				continue
+			}
+			_, hasF := body["set_temperature_f"]
+			_, hasC := body["set_temperature_c"]
+			assert.Equal(t, hasF, tc.req.SetTemperatureF != nil)
+			assert.Equal(t, hasC, tc.req.SetTemperatureC != nil)
+		}
+	}
+}
+
+func TestGetInUnit(t *testing.T) {
+	for _, tc := range []struct {
+		display   string
+		unit      DisplayTemperatureUnit
+		wantSet   float64
+		wantWater float64
+	}{
+		{display: "c", unit: DisplayTemperatureUnitF, wantSet: 69.8, wantWater: 64.4},
+		{display: "f", unit: DisplayTemperatureUnitC, wantSet: 21.1, wantWater: 18},
+		{display: "c", unit: DisplayTemperatureUnitC, wantSet: 21, wantWater: 18},
+		{display: "f", unit: DisplayTemperatureUnitF, wantSet: 70, wantWater: 64.4},
+	} {
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var details DeviceDetails
+			details.Control.DisplayTemperatureUnit = tc.display
+			details.Control.SetTemperatureC = 21
+			details.Control.SetTemperatureF = 70
+			details.Status.WaterTemperatureC = 18
+			details.Status.WaterTemperatureF = 64.4
+			json.NewEncoder(w).Encode(details)
+		}))
+
+		details, err := c.GetInUnit(context.Background(), "device", tc.unit)
+		assert.NilError(t, err)
+		assert.Equal(t, details.Unit, tc.unit)
+		assert.Equal(t, math.Round(details.SetTemperature*10)/10, tc.wantSet, "%s from %s", tc.unit, tc.display)
+		assert.Equal(t, details.WaterTemperature, tc.wantWater, "%s from %s", tc.unit, tc.display)
+		assert.Equal(t, math.Round(details.TemperatureDelta()*10)/10, math.Round((tc.wantSet-tc.wantWater)*10)/10)
+
+		raw, err := c.Get(context.Background(), "device")
+		assert.NilError(t, err)
+		assert.DeepEqual(t, details.Details, raw)
+		assert.Equal(t, details.Details.Control.DisplayTemperatureUnit, tc.display)
+	}
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	_, err := c.GetInUnit(context.Background(), "device", "k")
+	assert.ErrorContains(t, err, `unknown temperature unit "k"`)
+}
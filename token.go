@@ -0,0 +1,92 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// TokenSource supplies the bearer tokens used to authenticate requests
+type TokenSource interface {
+	// Token returns a token to use. It is called before the first request,
+	// and again whenever the API rejects the token previously returned
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// WithTokenSource authenticates requests with tokens from ts, enabling token rotation.
+// A request rejected with a 401 is sent once more with a new token from ts.
+// The token passed to New is ignored
+func WithTokenSource(ts TokenSource) func(*Client) error {
+	return func(c *Client) error {
+		if ts == nil {
+			return errors.New("token source must not be nil")
+		}
+		c.tokenSource = ts
+		c.token = ""
+		return nil
+	}
+}
+
+// currentToken returns the token for the next request, fetching one from the token source if necessary
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token == "" && c.tokenSource != nil {
+		token, err := c.tokenSource.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.token = normalizeToken(token)
+	}
+	return c.token, nil
+}
+
+// maxTokenRefreshes bounds how often a single call fetches a new token after the API rejected one,
+// so a token source handing out invalid tokens doesn't cause an endless loop
+const maxTokenRefreshes = 1
+
+// refreshToken replaces the rejected token with a new one from the token source and returns it.
+// Concurrent callers rejected with the same token share a single refresh: whoever comes second finds
+// the token already replaced and uses the new one
+func (c *Client) refreshToken(ctx context.Context, rejected string) (string, error) {
+	if c.tokenSource == nil {
+		return "", errors.New("no token source configured")
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token != "" && c.token != rejected {
+		return c.token, nil
+	}
+	token, err := c.tokenSource.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = normalizeToken(token)
+	return c.token, nil
+}
+
+// normalizeToken trims surrounding whitespace and a leading "Bearer " from token, in any case,
+// as tokens are easily copied along with them
+func normalizeToken(token string) string {
+	token = strings.TrimSpace(token)
+	if len(token) > len("bearer ") && strings.EqualFold(token[:len("bearer ")], "bearer ") {
+		token = strings.TrimSpace(token[len("bearer "):])
+	}
+	return token
+}
+
+// isUnauthorized reports whether err is the API rejecting a token
+func isUnauthorized(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == 401
+}
@@ -0,0 +1,161 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rotatingTokens hands out token-1, token-2, ... on every call
+type rotatingTokens struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *rotatingTokens) Token(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	return fmt.Sprintf("token-%d", r.calls), nil
+}
+
+func TestWatchDeviceSurvivesTokenRotation(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		valid    = "Bearer token-1"
+	)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		// the server rotates the token after the second request
+		if requests == 3 {
+			valid = "Bearer token-2"
+		}
+		if r.Header.Get("Authorization") != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}), WithTokenSource(&rotatingTokens{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errc := c.WatchDevice(ctx, "device", time.Millisecond)
+	var received int
+	for range out {
+		received++
+		if received == 4 {
+			cancel()
+		}
+	}
+	assert.NilError(t, <-errc)
+	assert.Assert(t, received >= 4, "expected the watch to continue after rotation")
+}
+
+func TestWithTokenSourceReadsTokenPerRequest(t *testing.T) {
+	var seen []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithTokenSource(&rotatingTokens{}))
+
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	_, err = c.refreshToken(context.Background(), "token-1")
+	assert.NilError(t, err)
+	_, err = c.ListDevices(context.Background())
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, seen, []string{"Bearer token-1", "Bearer token-2"})
+}
+
+func TestWatchDeviceStopsWithoutTokenSource(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	out, errc := c.WatchDevice(context.Background(), "device", time.Millisecond)
+	for range out {
+	}
+	assert.Assert(t, isUnauthorized(<-errc))
+}
+
+func TestConcurrentRequestsShareTokenRefresh(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		valid = "Bearer token-2"
+	)
+	tokens := &rotatingTokens{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}), WithTokenSource(tokens))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(context.Background(), "device")
+			assert.Check(t, err)
+		}()
+	}
+	wg.Wait()
+	// one token for the first requests, and a single refresh after all of them were rejected
+	assert.Equal(t, tokens.calls, 2)
+}
+
+func TestTokenRefreshIsBounded(t *testing.T) {
+	var requests int
+	tokens := &rotatingTokens{}
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}), WithTokenSource(tokens))
+
+	status := ThermalControlStatusActive
+	err := c.Update(context.Background(), "device", UpdateRequest{ThermalControlStatus: &status})
+	assert.Assert(t, isUnauthorized(err), "expected unauthorized, got %v", err)
+	assert.Equal(t, requests, 2)
+	assert.Equal(t, tokens.calls, 2)
+}
+
+func TestTokenIsNormalized(t *testing.T) {
+	for _, token := range []string{"secret", "Bearer secret", "bearer secret", " BEARER  secret\n", "\tsecret "} {
+		var seen string
+		srv := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = r.Header.Get("Authorization")
+			json.NewEncoder(w).Encode([]Device{})
+		}))
+		c, err := New(token, WithAPIEndpoint(srv.APIEndpoint))
+		assert.NilError(t, err)
+
+		_, err = c.ListDevices(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, seen, "Bearer secret", "token %q", token)
+	}
+
+	var seen string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("Authorization")
+		json.NewEncoder(w).Encode([]Device{})
+	}), WithTokenSource(TokenSourceFunc(func(ctx context.Context) (string, error) {
+		return "Bearer rotated", nil
+	})))
+	_, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, seen, "Bearer rotated")
+}
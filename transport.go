@@ -0,0 +1,120 @@
+package sleepme
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WithMinTLSVersion enforces a minimum TLS version, e.g. tls.VersionTLS13, for connections to the API.
+// Versions below TLS 1.2 are rejected; TLS 1.2 is also what Go uses by default
+func WithMinTLSVersion(version uint16) func(*Client) error {
+	return func(c *Client) error {
+		if version < tls.VersionTLS12 || version > tls.VersionTLS13 {
+			return fmt.Errorf("unsupported minimum TLS version %#04x", version)
+		}
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		if t.TLSClientConfig.InsecureSkipVerify {
+			return errors.New("a minimum TLS version can't be enforced WithInsecureSkipVerify")
+		}
+		t.TLSClientConfig.MinVersion = version
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of TLS certificates, e.g. for a local mock server
+// with a self-signed certificate.
+//
+// WARNING: this allows anyone on the network to impersonate the API and steal the token.
+// Never use it outside of tests and development. It can't be combined with WithMinTLSVersion,
+// and creating a client with it is logged, see WithLogger
+func WithInsecureSkipVerify() func(*Client) error {
+	return func(c *Client) error {
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		if t.TLSClientConfig.MinVersion != 0 {
+			return errors.New("a minimum TLS version can't be enforced WithInsecureSkipVerify")
+		}
+		t.TLSClientConfig.InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// WithDialTimeout limits how long establishing a connection to the API may take. Unlike http.Client.Timeout,
+// it doesn't limit slow responses, see WithResponseHeaderTimeout
+func WithDialTimeout(d time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("dial timeout must be positive, got %s", d)
+		}
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		t.DialContext = (&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext
+		return nil
+	}
+}
+
+// WithResponseHeaderTimeout limits how long the API may take to respond once a request was sent,
+// not including reading the body
+func WithResponseHeaderTimeout(d time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("response header timeout must be positive, got %s", d)
+		}
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		t.ResponseHeaderTimeout = d
+		return nil
+	}
+}
+
+// logInsecure logs clients created WithInsecureSkipVerify, so they don't go unnoticed. Clients without
+// a logger, see WithLogger, log to the standard logger instead
+func (c *Client) logInsecure() {
+	t, ok := c.Client.Transport.(*http.Transport)
+	if !ok || t.TLSClientConfig == nil || !t.TLSClientConfig.InsecureSkipVerify {
+		return
+	}
+	const msg = "sleepme: TLS certificate verification is disabled, never use WithInsecureSkipVerify in production"
+	if c.logger == nil {
+		log.Print(msg)
+		return
+	}
+	c.logf(msg)
+}
+
+// transport returns the transport of the client for configuration. The first call replaces the transport
+// shared by all http.Clients, or by all clients of an AccountManager, with a private copy, so configuring
+// one client doesn't affect others
+func (c *Client) transport() (*http.Transport, error) {
+	if c.Client.Transport == nil {
+		c.Client.Transport = http.DefaultTransport
+		c.sharedTransport = true
+	}
+	t, ok := c.Client.Transport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("the client uses a custom transport which can't be configured")
+	}
+	if c.sharedTransport {
+		t = t.Clone()
+		c.Client.Transport = t
+		c.sharedTransport = false
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	return t, nil
+}
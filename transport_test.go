@@ -0,0 +1,119 @@
+package sleepme
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithMinTLSVersion(t *testing.T) {
+	c, err := New("token", WithMinTLSVersion(tls.VersionTLS13))
+	assert.NilError(t, err)
+
+	transport, ok := c.Client.Transport.(*http.Transport)
+	assert.Assert(t, ok, "expected a private transport")
+	assert.Equal(t, transport.TLSClientConfig.MinVersion, uint16(tls.VersionTLS13))
+	assert.Assert(t, transport != http.DefaultTransport, "the shared default transport must not be modified")
+	assert.Assert(t, http.DefaultTransport.(*http.Transport).TLSClientConfig == nil ||
+		http.DefaultTransport.(*http.Transport).TLSClientConfig.MinVersion != tls.VersionTLS13)
+}
+
+func TestWithMinTLSVersionRejectsOldVersions(t *testing.T) {
+	for _, version := range []uint16{tls.VersionTLS10, tls.VersionTLS11, 0} {
+		_, err := New("token", WithMinTLSVersion(version))
+		assert.ErrorContains(t, err, "unsupported minimum TLS version")
+	}
+}
+
+func TestWithInsecureSkipVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Device{{ID: "device"}})
+	}))
+	defer srv.Close()
+
+	c, err := New("token", WithAPIEndpoint(srv.URL))
+	assert.NilError(t, err)
+	_, err = c.ListDevices(context.Background())
+	assert.ErrorContains(t, err, "certificate")
+
+	var logs bytes.Buffer
+	c, err = New("token", WithAPIEndpoint(srv.URL), WithLogger(log.New(&logs, "", 0)), WithInsecureSkipVerify())
+	assert.NilError(t, err)
+	devices, err := c.ListDevices(context.Background())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, devices, []Device{{ID: "device"}})
+	assert.Assert(t, strings.Contains(logs.String(), "TLS certificate verification is disabled"), logs.String())
+
+	// without a logger, the warning goes to the standard logger
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	_, err = New("token", WithAPIEndpoint(srv.URL), WithInsecureSkipVerify())
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(std.String(), "TLS certificate verification is disabled"), std.String())
+}
+
+func TestWithInsecureSkipVerifyRejectsMinTLSVersion(t *testing.T) {
+	_, err := New("token", WithInsecureSkipVerify(), WithMinTLSVersion(tls.VersionTLS13))
+	assert.ErrorContains(t, err, "can't be enforced WithInsecureSkipVerify")
+	_, err = New("token", WithMinTLSVersion(tls.VersionTLS13), WithInsecureSkipVerify())
+	assert.ErrorContains(t, err, "can't be enforced WithInsecureSkipVerify")
+}
+
+func TestWithResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/devices/slow" {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c, err := New("token", WithAPIEndpoint(srv.URL), WithResponseHeaderTimeout(50*time.Millisecond))
+	assert.NilError(t, err)
+	_, err = c.Get(context.Background(), "fast")
+	assert.NilError(t, err)
+	_, err = c.Get(context.Background(), "slow")
+	assert.ErrorContains(t, err, "timeout awaiting response headers")
+
+	_, err = New("token", WithResponseHeaderTimeout(0))
+	assert.ErrorContains(t, err, "must be positive")
+}
+
+func TestWithDialTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Device{})
+	}))
+	defer srv.Close()
+
+	c, err := New("token", WithAPIEndpoint(srv.URL), WithDialTimeout(time.Second))
+	assert.NilError(t, err)
+	_, err = c.ListDevices(context.Background())
+	assert.NilError(t, err)
+
+	// no connection can be established within a nanosecond, not even to a local server
+	c, err = New("token", WithAPIEndpoint(srv.URL), WithDialTimeout(time.Nanosecond))
+	assert.NilError(t, err)
+	_, err = c.ListDevices(context.Background())
+	var netErr net.Error
+	assert.Assert(t, errors.As(err, &netErr) && netErr.Timeout(), "expected a dial timeout, got %v", err)
+
+	_, err = New("token", WithDialTimeout(-time.Second))
+	assert.ErrorContains(t, err, "must be positive")
+}
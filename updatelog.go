@@ -0,0 +1,99 @@
+package sleepme
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UpdateRecord is an update applied by the client, see UpdateLog
+type UpdateRecord struct {
+	Time     time.Time
+	DeviceID string
+	// Request is the request as sent, after rounding, clamping and coalescing
+	Request UpdateRequest
+}
+
+// WithUpdateLog keeps the last size successful updates in memory, e.g. to find out which
+// automation changed a device at night. See UpdateLog
+func WithUpdateLog(size int) func(*Client) error {
+	return func(c *Client) error {
+		if size < 1 {
+			return fmt.Errorf("update log size must be at least 1, got %d", size)
+		}
+		c.updateLog = &updateLog{records: make([]UpdateRecord, 0, size)}
+		return nil
+	}
+}
+
+// UpdateLog returns the updates recorded WithUpdateLog, oldest first.
+// It returns nil for clients created without WithUpdateLog
+func (c *Client) UpdateLog() []UpdateRecord {
+	if c.updateLog == nil {
+		return nil
+	}
+	return c.updateLog.list()
+}
+
+// updateLog is a ring buffer of the latest updates
+type updateLog struct {
+	mu      sync.Mutex
+	records []UpdateRecord
+	next    int
+}
+
+func (l *updateLog) add(record UpdateRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.records) < cap(l.records) {
+		l.records = append(l.records, record)
+		return
+	}
+	l.records[l.next] = record
+	l.next = (l.next + 1) % len(l.records)
+}
+
+func (l *updateLog) list() []UpdateRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records := make([]UpdateRecord, 0, len(l.records))
+	records = append(records, l.records[l.next:]...)
+	return append(records, l.records[:l.next]...)
+}
+
+// recordUpdate adds an update which was applied successfully to the update log
+func (c *Client) recordUpdate(deviceID string, r UpdateRequest) {
+	if c.updateLog == nil {
+		return
+	}
+	c.updateLog.add(UpdateRecord{Time: c.clock.Now(), DeviceID: deviceID, Request: r.clone()})
+}
+
+// clone copies r, so callers changing the values r points to don't change the copy
+func (r UpdateRequest) clone() UpdateRequest {
+	if r.ThermalControlStatus != nil {
+		v := *r.ThermalControlStatus
+		r.ThermalControlStatus = &v
+	}
+	if r.SetTemperatureF != nil {
+		v := *r.SetTemperatureF
+		r.SetTemperatureF = &v
+	}
+	if r.SetTemperatureC != nil {
+		v := *r.SetTemperatureC
+		r.SetTemperatureC = &v
+	}
+	if r.DisplayTemperatureUnit != nil {
+		v := *r.DisplayTemperatureUnit
+		r.DisplayTemperatureUnit = &v
+	}
+	if r.TimeZone != nil {
+		v := *r.TimeZone
+		r.TimeZone = &v
+	}
+	if r.BrightnessLevel != nil {
+		v := *r.BrightnessLevel
+		r.BrightnessLevel = &v
+	}
+	return r
+}
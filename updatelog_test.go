@@ -0,0 +1,70 @@
+package sleepme
+
+import (
+	"context"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateLog(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 3, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/devices/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}), WithClock(clock), WithUpdateLog(2))
+
+	f := 72.4
+	assert.NilError(t, c.Update(context.Background(), "left", UpdateRequest{SetTemperatureF: &f}))
+	clock.Advance(time.Minute)
+	assert.Assert(t, c.Update(context.Background(), "broken", UpdateRequest{SetTemperatureF: &f}) != nil)
+	status := ThermalControlStatusStandby
+	assert.NilError(t, c.Update(context.Background(), "right", UpdateRequest{ThermalControlStatus: &status}))
+	f = 60
+
+	rounded := float64(72)
+	assert.DeepEqual(t, c.UpdateLog(), []UpdateRecord{
+		{Time: clock.Now().Add(-time.Minute), DeviceID: "left", Request: UpdateRequest{SetTemperatureF: &rounded}},
+		{Time: clock.Now(), DeviceID: "right", Request: UpdateRequest{ThermalControlStatus: &status}},
+	})
+
+	// the log keeps only the latest updates
+	clock.Advance(time.Minute)
+	level := 10
+	assert.NilError(t, c.Update(context.Background(), "left", UpdateRequest{BrightnessLevel: &level}))
+	records := c.UpdateLog()
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].DeviceID, "right")
+	assert.Equal(t, records[1].DeviceID, "left")
+	assert.Equal(t, *records[1].Request.BrightnessLevel, 10)
+	assert.Assert(t, records[1].Time.Equal(clock.Now()))
+}
+
+func TestUpdateLogDisabled(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	level := 10
+	assert.NilError(t, c.Update(context.Background(), "device", UpdateRequest{BrightnessLevel: &level}))
+	assert.Assert(t, c.UpdateLog() == nil)
+
+	_, err := New("token", WithUpdateLog(0))
+	assert.ErrorContains(t, err, "at least 1")
+}
+
+func TestUpdateLogConcurrent(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), WithUpdateLog(5))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(level int) {
+			defer wg.Done()
+			assert.Check(t, c.Update(context.Background(), "device", UpdateRequest{BrightnessLevel: &level}))
+			c.UpdateLog()
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, len(c.UpdateLog()), 5)
+}
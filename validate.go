@@ -0,0 +1,123 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MinTemperatureF is the lowest set temperature a Dock Pro accepts, in Fahrenheit
+	MinTemperatureF = 55
+	// MaxTemperatureF is the highest set temperature a Dock Pro accepts, in Fahrenheit
+	MaxTemperatureF = 115
+	// MinTemperatureC is the lowest set temperature a Dock Pro accepts, in Celsius
+	MinTemperatureC = 13
+	// MaxTemperatureC is the highest set temperature a Dock Pro accepts, in Celsius
+	MaxTemperatureC = 46
+)
+
+// ValidationError lists every problem found while validating an UpdateRequest
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid update request: %s", strings.Join(e.Problems, "; "))
+}
+
+// WithSkipValidation disables the local validation Update performs before sending a request
+func WithSkipValidation() func(*Client) error {
+	return func(c *Client) error {
+		c.skipValidation = true
+		return nil
+	}
+}
+
+// Validate checks every field set on the request against the constraints of the API.
+// All problems are reported at once through a *ValidationError
+func (r UpdateRequest) Validate() error {
+	return r.validate(true)
+}
+
+// validate is Validate, optionally skipping the range checks of the set temperatures
+func (r UpdateRequest) validate(checkTemperatureRange bool) error {
+	var problems []string
+	if r.ThermalControlStatus != nil && !r.ThermalControlStatus.valid() {
+		problems = append(problems, fmt.Sprintf("unknown thermal_control_status %q", *r.ThermalControlStatus))
+	}
+	if r.SetTemperatureF != nil && checkTemperatureRange {
+		if f := *r.SetTemperatureF; f < MinTemperatureF || f > MaxTemperatureF {
+			problems = append(problems, fmt.Sprintf("set_temperature_f %v is outside of [%d, %d]", f, MinTemperatureF, MaxTemperatureF))
+		}
+	}
+	if r.SetTemperatureC != nil && checkTemperatureRange {
+		if c := *r.SetTemperatureC; c < MinTemperatureC || c > MaxTemperatureC {
+			problems = append(problems, fmt.Sprintf("set_temperature_c %v is outside of [%d, %d]", c, MinTemperatureC, MaxTemperatureC))
+		}
+	}
+	if r.DisplayTemperatureUnit != nil && !r.DisplayTemperatureUnit.valid() {
+		problems = append(problems, fmt.Sprintf("unknown display_temperature_unit %q", *r.DisplayTemperatureUnit))
+	}
+	if r.TimeZone != nil {
+		if *r.TimeZone == "" {
+			problems = append(problems, "time_zone must not be empty")
+		} else if _, err := time.LoadLocation(*r.TimeZone); err != nil {
+			problems = append(problems, fmt.Sprintf("unknown time_zone %q", *r.TimeZone))
+		}
+	}
+	if r.BrightnessLevel != nil {
+		if b := *r.BrightnessLevel; b < 0 || b > 100 {
+			problems = append(problems, fmt.Sprintf("brightness_level %d is outside of [0, 100]", b))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateForDevice checks r like Validate, but against the temperature range and features of the device
+// according to its Capabilities, e.g. rejecting a brightness level for a model without a display.
+// The capabilities known from the last Get of the device are used; unknown devices are fetched first
+func (c *Client) ValidateForDevice(ctx context.Context, deviceID string, r UpdateRequest) error {
+	capabilities, ok := c.capabilities.lookup(deviceID)
+	if !ok {
+		details, err := c.Get(ctx, deviceID)
+		if err != nil {
+			return err
+		}
+		capabilities = details.Capabilities()
+	}
+	return r.validateFor(capabilities)
+}
+
+// validateFor is Validate with the temperature range and features given by capabilities
+func (r UpdateRequest) validateFor(capabilities Capabilities) error {
+	var problems []string
+	var verr *ValidationError
+	if err := r.validate(false); errors.As(err, &verr) {
+		problems = verr.Problems
+	}
+	if r.SetTemperatureF != nil {
+		if f := *r.SetTemperatureF; f < capabilities.MinTemperatureF || f > capabilities.MaxTemperatureF {
+			problems = append(problems, fmt.Sprintf("set_temperature_f %v is outside of [%v, %v]", f, capabilities.MinTemperatureF, capabilities.MaxTemperatureF))
+		}
+	}
+	if r.SetTemperatureC != nil {
+		if c := *r.SetTemperatureC; c < capabilities.MinTemperatureC || c > capabilities.MaxTemperatureC {
+			problems = append(problems, fmt.Sprintf("set_temperature_c %v is outside of [%v, %v]", c, capabilities.MinTemperatureC, capabilities.MaxTemperatureC))
+		}
+	}
+	if r.BrightnessLevel != nil && !capabilities.SupportsBrightness {
+		problems = append(problems, "brightness_level is not supported by the device")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateRequestValidate(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	i := func(v int) *int { return &v }
+	s := func(v string) *string { return &v }
+	status := func(v ThermalControlStatus) *ThermalControlStatus { return &v }
+	unit := func(v DisplayTemperatureUnit) *DisplayTemperatureUnit { return &v }
+
+	for _, tc := range []struct {
+		name     string
+		req      UpdateRequest
+		problems []string
+	}{
+		{name: "empty", req: UpdateRequest{}},
+		{
+			name: "valid",
+			req: UpdateRequest{
+				ThermalControlStatus:   status(ThermalControlStatusActive),
+				SetTemperatureF:        f(72),
+				SetTemperatureC:        f(22),
+				DisplayTemperatureUnit: unit(DisplayTemperatureUnitC),
+				TimeZone:               s("Europe/Berlin"),
+				BrightnessLevel:        i(100),
+			},
+		},
+		{name: "range bounds", req: UpdateRequest{SetTemperatureF: f(MinTemperatureF), SetTemperatureC: f(MaxTemperatureC), BrightnessLevel: i(0)}},
+		{name: "unknown status", req: UpdateRequest{ThermalControlStatus: status("off")}, problems: []string{`unknown thermal_control_status "off"`}},
+		{name: "too cold f", req: UpdateRequest{SetTemperatureF: f(54)}, problems: []string{"set_temperature_f 54 is outside of [55, 115]"}},
+		{name: "too hot c", req: UpdateRequest{SetTemperatureC: f(46.5)}, problems: []string{"set_temperature_c 46.5 is outside of [13, 46]"}},
+		{name: "unknown unit", req: UpdateRequest{DisplayTemperatureUnit: unit("k")}, problems: []string{`unknown display_temperature_unit "k"`}},
+		{name: "empty time zone", req: UpdateRequest{TimeZone: s("")}, problems: []string{"time_zone must not be empty"}},
+		{name: "unknown time zone", req: UpdateRequest{TimeZone: s("Mars/Olympus")}, problems: []string{`unknown time_zone "Mars/Olympus"`}},
+		{name: "brightness", req: UpdateRequest{BrightnessLevel: i(101)}, problems: []string{"brightness_level 101 is outside of [0, 100]"}},
+		{
+			name: "combined",
+			req:  UpdateRequest{SetTemperatureF: f(200), BrightnessLevel: i(-1)},
+			problems: []string{
+				"set_temperature_f 200 is outside of [55, 115]",
+				"brightness_level -1 is outside of [0, 100]",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.req.Validate()
+			if tc.problems == nil {
+				assert.NilError(t, err)
+				return
+			}
+			var verr *ValidationError
+			assert.Assert(t, errors.As(err, &verr), "expected a validation error, got %v", err)
+			assert.DeepEqual(t, verr.Problems, tc.problems)
+		})
+	}
+}
+
+func TestUpdateValidatesRequest(t *testing.T) {
+	var calls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+	temperature := float64(200)
+	req := UpdateRequest{SetTemperatureF: &temperature}
+
+	c := newTestClient(t, handler)
+	var verr *ValidationError
+	assert.Assert(t, errors.As(c.Update(context.Background(), "device", req), &verr))
+	assert.Equal(t, calls, 0)
+
+	c = newTestClient(t, handler, WithSkipValidation())
+	assert.NilError(t, c.Update(context.Background(), "device", req))
+	assert.Equal(t, calls, 1)
+}
+
+func TestValidateForDevice(t *testing.T) {
+	table := DefaultCapabilities
+	t.Cleanup(func() { DefaultCapabilities = table })
+	DefaultCapabilities = CapabilityTable{
+		Rules: []CapabilityRule{
+			{ModelPrefix: "DP", Capabilities: Capabilities{MinTemperatureF: 60, MaxTemperatureF: 100, MinTemperatureC: 16, MaxTemperatureC: 38, SupportsBrightness: true}},
+		},
+		Fallback: table.Fallback,
+	}
+
+	var gets int
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		var details DeviceDetails
+		details.About.Model = map[string]string{"/devices/pro": "DP999NA", "/devices/plain": "XS100"}[r.URL.Path]
+		json.NewEncoder(w).Encode(details)
+	}))
+	f := func(v float64) *float64 { return &v }
+	level := 50
+	status := ThermalControlStatus("off")
+
+	assert.NilError(t, c.ValidateForDevice(context.Background(), "pro", UpdateRequest{SetTemperatureF: f(60), BrightnessLevel: &level}))
+	for _, tc := range []struct {
+		deviceID string
+		req      UpdateRequest
+		problems []string
+	}{
+		{deviceID: "pro", req: UpdateRequest{SetTemperatureF: f(58)}, problems: []string{"set_temperature_f 58 is outside of [60, 100]"}},
+		{deviceID: "pro", req: UpdateRequest{SetTemperatureC: f(40)}, problems: []string{"set_temperature_c 40 is outside of [16, 38]"}},
+		{deviceID: "plain", req: UpdateRequest{SetTemperatureF: f(58), BrightnessLevel: &level}, problems: []string{"brightness_level is not supported by the device"}},
+		{
+			deviceID: "plain",
+			req:      UpdateRequest{ThermalControlStatus: &status, SetTemperatureC: f(50)},
+			problems: []string{`unknown thermal_control_status "off"`, "set_temperature_c 50 is outside of [13, 46]"},
+		},
+	} {
+		err := c.ValidateForDevice(context.Background(), tc.deviceID, tc.req)
+		var verr *ValidationError
+		assert.Assert(t, errors.As(err, &verr), "expected a validation error, got %v", err)
+		assert.DeepEqual(t, verr.Problems, tc.problems)
+	}
+	assert.Equal(t, gets, 2, "capabilities of known devices are reused")
+}
@@ -0,0 +1,54 @@
+package sleepme
+
+import "fmt"
+
+// WarningCode identifies the kind of a Warning
+type WarningCode string
+
+var (
+	// WarningUnknownEnumValue is raised when the API returns a value this package doesn't know,
+	// e.g. a new thermal control status
+	WarningUnknownEnumValue WarningCode = "unknown_enum_value"
+	// WarningTemperatureRounded is raised when a set temperature was rounded before sending it
+	WarningTemperatureRounded WarningCode = "temperature_rounded"
+	// WarningTemperatureClamped is raised when a set temperature was clamped into the supported range, see PolicyClamp
+	WarningTemperatureClamped WarningCode = "temperature_clamped"
+)
+
+// Warning describes a condition which isn't an error, but which users of the client should know about
+type Warning struct {
+	Code    WarningCode
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Code, w.Message)
+}
+
+// WithWarningHandler calls fn for every warning raised by the client. Warnings are ignored by default
+func WithWarningHandler(fn func(Warning)) func(*Client) error {
+	return func(c *Client) error {
+		c.warningHandler = fn
+		return nil
+	}
+}
+
+func (c *Client) warn(code WarningCode, format string, args ...interface{}) {
+	if c.warningHandler != nil {
+		c.warningHandler(Warning{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// checkDetails raises warnings for values in details this package doesn't know
+func (c *Client) checkDetails(deviceID string, details *DeviceDetails) {
+	switch ThermalControlStatus(details.Control.ThermalControlStatus) {
+	case ThermalControlStatusActive, ThermalControlStatusStandby, "":
+	default:
+		c.warn(WarningUnknownEnumValue, "device %s reports unknown thermal_control_status %q", deviceID, details.Control.ThermalControlStatus)
+	}
+	switch DisplayTemperatureUnit(details.Control.DisplayTemperatureUnit) {
+	case DisplayTemperatureUnitC, DisplayTemperatureUnitF, "":
+	default:
+		c.warn(WarningUnknownEnumValue, "device %s reports unknown display_temperature_unit %q", deviceID, details.Control.DisplayTemperatureUnit)
+	}
+}
@@ -0,0 +1,38 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+func TestWarningHandler(t *testing.T) {
+	var warnings []Warning
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			return
+		}
+		var details DeviceDetails
+		details.Control.ThermalControlStatus = "boost"
+		details.Control.DisplayTemperatureUnit = "f"
+		json.NewEncoder(w).Encode(details)
+	}), WithWarningHandler(func(w Warning) {
+		warnings = append(warnings, w)
+	}))
+
+	_, err := c.Get(context.Background(), "device")
+	assert.NilError(t, err)
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 70.4))
+
+	assert.DeepEqual(t, warnings, []Warning{
+		{Code: WarningUnknownEnumValue, Message: `device device reports unknown thermal_control_status "boost"`},
+		{Code: WarningTemperatureRounded, Message: "set_temperature_f 70.4 was rounded to 70"},
+	})
+}
+
+func TestWarningsAreIgnoredByDefault(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	assert.NilError(t, c.SetTemperatureF(context.Background(), "device", 70.4))
+}
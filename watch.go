@@ -0,0 +1,186 @@
+package sleepme
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WatchDevice polls a Dock Pro every interval and emits its details.
+// Both channels are closed once ctx is cancelled or a request fails, in which case
+// the error is sent on the error channel first. A token rejected mid-watch is refreshed
+// when the client was created WithTokenSource, like for every other request
+func (c *Client) WatchDevice(ctx context.Context, deviceID string, interval time.Duration) (<-chan *DeviceDetails, <-chan error) {
+	out := make(chan *DeviceDetails)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		err := c.poll(ctx, deviceID, interval, func(details *DeviceDetails) error {
+			select {
+			case out <- details:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
+
+// poll fetches the details of a device right away and then interval after each fetch, handing each result to fn.
+// It returns nil once ctx is cancelled, or the first error from either Get or fn. A non-positive interval is
+// rejected before fetching anything, as it would poll the API in a tight loop
+func (c *Client) poll(ctx context.Context, deviceID string, interval time.Duration, fn func(*DeviceDetails) error) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	return c.pollAdaptive(ctx, deviceID, func(*DeviceDetails) time.Duration { return interval }, fn)
+}
+
+// pollAdaptive is poll, waiting for the interval returned for the latest details after each fetch.
+// It fails once interval returns a non-positive interval
+func (c *Client) pollAdaptive(ctx context.Context, deviceID string, interval func(*DeviceDetails) time.Duration, fn func(*DeviceDetails) error) error {
+	for {
+		details, err := c.Get(ctx, deviceID)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c.recorder != nil {
+			c.recorder.Record(deviceID, c.clock.Now(), details)
+		}
+		if err := fn(details); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		next := interval(details)
+		if next <= 0 {
+			return fmt.Errorf("interval must be positive, got %s", next)
+		}
+		if c.sleepCtx(ctx, next) != nil {
+			return nil
+		}
+	}
+}
+
+// WatchDeviceAdaptive is WatchDevice, polling every active interval while the Dock Pro heats or cools
+// towards its set temperature, and every idle interval while it is in standby or holds its set temperature,
+// see ActiveMode. This keeps progress responsive without spending the rate limit on idle devices
+func (c *Client) WatchDeviceAdaptive(ctx context.Context, deviceID string, idle, active time.Duration) (<-chan *DeviceDetails, <-chan error) {
+	out := make(chan *DeviceDetails)
+	errc := make(chan error, 1)
+	if idle <= 0 || active <= 0 {
+		errc <- fmt.Errorf("intervals must be positive, got %s idle and %s active", idle, active)
+		close(out)
+		close(errc)
+		return out, errc
+	}
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		interval := func(details *DeviceDetails) time.Duration {
+			if mode := details.ActiveMode(); mode == ModeHeat || mode == ModeCool {
+				return active
+			}
+			return idle
+		}
+		err := c.pollAdaptive(ctx, deviceID, interval, func(details *DeviceDetails) error {
+			select {
+			case out <- details:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
+
+// WatchSmoothedTemperature polls a Dock Pro every interval and emits its water temperature in Celsius,
+// exponentially smoothed across readings: each value is alpha * reading + (1 - alpha) * previous value.
+// alpha must be in (0, 1]; an alpha of 1 disables smoothing
+func (c *Client) WatchSmoothedTemperature(ctx context.Context, deviceID string, alpha float64, interval time.Duration) (<-chan float64, <-chan error) {
+	out := make(chan float64)
+	errc := make(chan error, 1)
+	if !(alpha > 0 && alpha <= 1) {
+		errc <- fmt.Errorf("alpha must be in (0, 1], got %v", alpha)
+		close(out)
+		close(errc)
+		return out, errc
+	}
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		var (
+			smoothed float64
+			seeded   bool
+		)
+		err := c.poll(ctx, deviceID, interval, func(details *DeviceDetails) error {
+			reading := details.Status.WaterTemperatureC
+			if seeded {
+				smoothed = alpha*reading + (1-alpha)*smoothed
+			} else {
+				smoothed, seeded = reading, true
+			}
+			select {
+			case out <- smoothed:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
+
+// WatchConnection polls a Dock Pro every interval and emits whether it is connected: once for the first
+// fetch, and then only when the connection state changes. The channels behave like those of WatchDevice
+func (c *Client) WatchConnection(ctx context.Context, deviceID string, interval time.Duration) (<-chan bool, <-chan error) {
+	out := make(chan bool)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		var (
+			connected bool
+			seen      bool
+		)
+		err := c.poll(ctx, deviceID, interval, func(details *DeviceDetails) error {
+			if seen && details.Status.IsConnected == connected {
+				return nil
+			}
+			connected, seen = details.Status.IsConnected, true
+			select {
+			case out <- connected:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
@@ -0,0 +1,208 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchSmoothedTemperature(t *testing.T) {
+	readings := []float64{20, 30, 30, 10}
+	var calls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&calls, 1)) - 1
+		var details DeviceDetails
+		details.Status.WaterTemperatureC = readings[i%len(readings)]
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out, errc := c.WatchSmoothedTemperature(ctx, "device", 0.5, time.Millisecond)
+	var got []float64
+	for v := range out {
+		got = append(got, v)
+		if len(got) == len(readings) {
+			cancel()
+		}
+	}
+	assert.NilError(t, <-errc)
+	assert.DeepEqual(t, got[:len(readings)], []float64{20, 25, 27.5, 18.75})
+}
+
+func TestWatchSmoothedTemperatureInvalidAlpha(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+
+	for _, alpha := range []float64{0, -0.5, 1.5} {
+		out, errc := c.WatchSmoothedTemperature(context.Background(), "device", alpha, time.Millisecond)
+		_, ok := <-out
+		assert.Assert(t, !ok, "expected no readings for alpha %v", alpha)
+		assert.ErrorContains(t, <-errc, "alpha must be in (0, 1]")
+	}
+}
+
+func TestWatchDeviceStopsOnError(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	out, errc := c.WatchDevice(context.Background(), "device", time.Millisecond)
+	_, ok := <-out
+	assert.Assert(t, !ok, "expected no details")
+	assert.ErrorContains(t, <-errc, "expected 200, got 500")
+}
+
+func TestWatchDeviceStopsPromptlyOnCancel(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}), WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, errc := c.WatchDevice(ctx, "device", time.Hour)
+	<-out
+	// the clock never advances, so the watch only returns if waiting for the next poll is cancelled
+	clock.BlockUntil(1)
+	cancel()
+
+	select {
+	case err := <-errc:
+		assert.NilError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the watch kept waiting for its next poll after being cancelled")
+	}
+	_, open := <-out
+	assert.Assert(t, !open)
+}
+
+func TestWatchConnection(t *testing.T) {
+	states := []bool{false, false, true, true, true, false, true}
+	var polls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		poll := int(atomic.AddInt32(&polls, 1)) - 1
+		var details DeviceDetails
+		details.Status.IsConnected = states[poll%len(states)]
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, errc := c.WatchConnection(ctx, "device", time.Millisecond)
+	var got []bool
+	for connected := range out {
+		got = append(got, connected)
+		if len(got) == 4 {
+			cancel()
+		}
+	}
+	assert.NilError(t, <-errc)
+	assert.DeepEqual(t, got, []bool{false, true, false, true})
+	assert.Assert(t, atomic.LoadInt32(&polls) >= 7)
+}
+
+func TestWatchDeviceAdaptive(t *testing.T) {
+	type state struct {
+		status string
+		water  float64
+	}
+	states := []state{
+		{status: "standby", water: 60},
+		{status: "active", water: 60},
+		{status: "active", water: 75},
+		{status: "active", water: 79.5},
+		{status: "standby", water: 79.5},
+	}
+	clock := newFakeClock(time.Date(2023, 1, 1, 22, 0, 0, 0, time.UTC))
+	var polls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := states[int(atomic.AddInt32(&polls, 1))-1]
+		var details DeviceDetails
+		details.Control.ThermalControlStatus = s.status
+		details.Control.SetTemperatureF = 80
+		details.Status.WaterTemperatureF = s.water
+		json.NewEncoder(w).Encode(details)
+	}), WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, errc := c.WatchDeviceAdaptive(ctx, "device", 10*time.Minute, 30*time.Second)
+	var intervals []time.Duration
+	for range states {
+		<-out
+		clock.BlockUntil(1)
+		clock.mu.Lock()
+		interval := clock.waiters[0].at.Sub(clock.now)
+		clock.mu.Unlock()
+		intervals = append(intervals, interval)
+		if len(intervals) < len(states) {
+			clock.Advance(interval)
+		}
+	}
+	cancel()
+	assert.NilError(t, <-errc)
+	assert.DeepEqual(t, intervals, []time.Duration{10 * time.Minute, 30 * time.Second, 30 * time.Second, 10 * time.Minute, 10 * time.Minute})
+}
+
+func TestWatchersRejectNonPositiveIntervals(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	ctx := context.Background()
+	for name, watch := range map[string]func() error{
+		"WatchDevice": func() error {
+			out, errc := c.WatchDevice(ctx, "device", 0)
+			for range out {
+			}
+			return <-errc
+		},
+		"WatchDeviceAdaptive": func() error {
+			out, errc := c.WatchDeviceAdaptive(ctx, "device", time.Minute, 0)
+			for range out {
+			}
+			return <-errc
+		},
+		"WatchSmoothedTemperature": func() error {
+			out, errc := c.WatchSmoothedTemperature(ctx, "device", 1, -time.Second)
+			for range out {
+			}
+			return <-errc
+		},
+		"WatchConnection": func() error {
+			out, errc := c.WatchConnection(ctx, "device", 0)
+			for range out {
+			}
+			return <-errc
+		},
+		"WatchWaterBands": func() error {
+			out, errc := c.WatchWaterBands(ctx, "device", []int{50}, 0)
+			for range out {
+			}
+			return <-errc
+		},
+		"MirrorTemperature": func() error {
+			return c.MirrorTemperature(ctx, "primary", "secondary", 0)
+		},
+	} {
+		assert.ErrorContains(t, watch(), "must be positive", name)
+	}
+}
+
+func TestPollAdaptiveRejectsNonPositiveIntervals(t *testing.T) {
+	var polls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&polls, 1)
+		json.NewEncoder(w).Encode(DeviceDetails{})
+	}))
+
+	err := c.pollAdaptive(context.Background(), "device", func(*DeviceDetails) time.Duration { return 0 }, func(*DeviceDetails) error {
+		return nil
+	})
+	assert.Error(t, err, "interval must be positive, got 0s")
+	assert.Equal(t, atomic.LoadInt32(&polls), int32(1))
+}
@@ -0,0 +1,153 @@
+package sleepme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WaterLevelState is a coarse water level for UIs, see DeviceDetails.WaterLevelState
+type WaterLevelState string
+
+const (
+	WaterLevelFull  WaterLevelState = "full"
+	WaterLevelOK    WaterLevelState = "ok"
+	WaterLevelLow   WaterLevelState = "low"
+	WaterLevelEmpty WaterLevelState = "empty"
+)
+
+const (
+	// waterLevelFullPercent is the level from which the reservoir counts as full
+	waterLevelFullPercent = 90
+	// waterLevelLowPercent is the level below which the reservoir counts as low, even if the device doesn't flag it yet
+	waterLevelLowPercent = 20
+)
+
+// WaterLevelPercent returns Status.WaterLevel as a percentage of a full reservoir.
+// The API doesn't document its scale; the devices seen so far report percentages, so the level
+// is only clamped to [0, 100] in case others don't
+func (d *DeviceDetails) WaterLevelPercent() int {
+	switch level := d.Status.WaterLevel; {
+	case level < 0:
+		return 0
+	case level > 100:
+		return 100
+	default:
+		return level
+	}
+}
+
+// WaterLevelState classifies the water level. A device flagging its water as low is never reported
+// as more than WaterLevelLow, whatever its level, as the flag is what stops it from heating or cooling
+func (d *DeviceDetails) WaterLevelState() WaterLevelState {
+	level := d.WaterLevelPercent()
+	switch {
+	case level == 0:
+		return WaterLevelEmpty
+	case d.Status.IsWaterLow || level < waterLevelLowPercent:
+		return WaterLevelLow
+	case level >= waterLevelFullPercent:
+		return WaterLevelFull
+	default:
+		return WaterLevelOK
+	}
+}
+
+// WaterBandDirection tells whether the water level crossed a band upwards or downwards, see WatchWaterBands
+type WaterBandDirection string
+
+const (
+	WaterBandRising  WaterBandDirection = "rising"
+	WaterBandFalling WaterBandDirection = "falling"
+)
+
+// WaterBandEvent reports the water level, in percent, crossing Band
+type WaterBandEvent struct {
+	Band      int
+	Direction WaterBandDirection
+	Level     int
+}
+
+// WatchWaterBands polls a Dock Pro every interval and emits an event whenever its water level, see WaterLevelPercent,
+// crosses one of bands: falling once it drops below a band, and rising once it is back at or above it.
+// The first fetch only records the level. A change crossing several bands emits one event per band, in the order
+// they were crossed. bands must be within [1, 100]. The channels behave like those of WatchDevice
+func (c *Client) WatchWaterBands(ctx context.Context, deviceID string, bands []int, interval time.Duration) (<-chan WaterBandEvent, <-chan error) {
+	out := make(chan WaterBandEvent)
+	errc := make(chan error, 1)
+	sorted := append([]int(nil), bands...)
+	sort.Ints(sorted)
+	if err := validateWaterBands(sorted); err != nil {
+		errc <- err
+		close(out)
+		close(errc)
+		return out, errc
+	}
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		var (
+			previous int
+			seen     bool
+		)
+		err := c.poll(ctx, deviceID, interval, func(details *DeviceDetails) error {
+			level := details.WaterLevelPercent()
+			events := waterBandEvents(sorted, previous, level)
+			if !seen {
+				events = nil
+			}
+			previous, seen = level, true
+			for _, event := range events {
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
+
+func validateWaterBands(sorted []int) error {
+	if len(sorted) == 0 {
+		return errors.New("at least one water band is required")
+	}
+	for i, band := range sorted {
+		if band < 1 || band > 100 {
+			return fmt.Errorf("water bands must be within [1, 100], got %d", band)
+		}
+		if i > 0 && sorted[i-1] == band {
+			return fmt.Errorf("duplicate water band %d", band)
+		}
+	}
+	return nil
+}
+
+// waterBandEvents returns the events for the level changing from previous to level, given sorted bands
+func waterBandEvents(sorted []int, previous, level int) []WaterBandEvent {
+	var events []WaterBandEvent
+	switch {
+	case level < previous:
+		for i := len(sorted) - 1; i >= 0; i-- {
+			if band := sorted[i]; level < band && band <= previous {
+				events = append(events, WaterBandEvent{Band: band, Direction: WaterBandFalling, Level: level})
+			}
+		}
+	case level > previous:
+		for _, band := range sorted {
+			if previous < band && band <= level {
+				events = append(events, WaterBandEvent{Band: band, Direction: WaterBandRising, Level: level})
+			}
+		}
+	}
+	return events
+}
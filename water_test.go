@@ -0,0 +1,92 @@
+package sleepme
+
+import (
+	"context"
+	"encoding/json"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaterLevel(t *testing.T) {
+	for _, tc := range []struct {
+		level   int
+		low     bool
+		percent int
+		state   WaterLevelState
+	}{
+		{level: -5, percent: 0, state: WaterLevelEmpty},
+		{level: 0, percent: 0, state: WaterLevelEmpty},
+		{level: 0, low: true, percent: 0, state: WaterLevelEmpty},
+		{level: 10, percent: 10, state: WaterLevelLow},
+		{level: 19, percent: 19, state: WaterLevelLow},
+		{level: 20, percent: 20, state: WaterLevelOK},
+		{level: 50, percent: 50, state: WaterLevelOK},
+		{level: 50, low: true, percent: 50, state: WaterLevelLow},
+		{level: 89, percent: 89, state: WaterLevelOK},
+		{level: 90, percent: 90, state: WaterLevelFull},
+		{level: 100, percent: 100, state: WaterLevelFull},
+		{level: 100, low: true, percent: 100, state: WaterLevelLow},
+		{level: 130, percent: 100, state: WaterLevelFull},
+	} {
+		var details DeviceDetails
+		details.Status.WaterLevel = tc.level
+		details.Status.IsWaterLow = tc.low
+		assert.Equal(t, details.WaterLevelPercent(), tc.percent, "level %d", tc.level)
+		assert.Equal(t, details.WaterLevelState(), tc.state, "level %d, low %v", tc.level, tc.low)
+	}
+}
+
+func TestWatchWaterBands(t *testing.T) {
+	levels := []int{80, 70, 20, 8, 8, 30, 60, 100}
+	var polls int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		poll := int(atomic.AddInt32(&polls, 1)) - 1
+		var details DeviceDetails
+		details.Status.WaterLevel = levels[poll%len(levels)]
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, errc := c.WatchWaterBands(ctx, "device", []int{25, 75, 10, 50}, time.Millisecond)
+	var got []WaterBandEvent
+	for event := range out {
+		got = append(got, event)
+		if len(got) == 8 {
+			cancel()
+		}
+	}
+	assert.NilError(t, <-errc)
+	assert.DeepEqual(t, got, []WaterBandEvent{
+		{Band: 75, Direction: WaterBandFalling, Level: 70},
+		{Band: 50, Direction: WaterBandFalling, Level: 20},
+		{Band: 25, Direction: WaterBandFalling, Level: 20},
+		{Band: 10, Direction: WaterBandFalling, Level: 8},
+		{Band: 10, Direction: WaterBandRising, Level: 30},
+		{Band: 25, Direction: WaterBandRising, Level: 30},
+		{Band: 50, Direction: WaterBandRising, Level: 60},
+		{Band: 75, Direction: WaterBandRising, Level: 100},
+	})
+}
+
+func TestWatchWaterBandsRejectsInvalidBands(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	for _, tc := range []struct {
+		bands []int
+		want  string
+	}{
+		{want: "at least one water band is required"},
+		{bands: []int{50, 0}, want: "water bands must be within [1, 100], got 0"},
+		{bands: []int{25, 50, 25}, want: "duplicate water band 25"},
+	} {
+		out, errc := c.WatchWaterBands(context.Background(), "device", tc.bands, time.Millisecond)
+		for range out {
+		}
+		assert.ErrorContains(t, <-errc, tc.want)
+	}
+}